@@ -0,0 +1,128 @@
+// Comando strategy executa pela linha de comando qualquer estratégia do registro do calculador.
+//
+//	go run ./cmd/strategy -list
+//	go run ./cmd/strategy -strategy dca -symbol ^GSPC -start 2020-01-01 -p amount=200 -p frequency=weekly
+package main
+
+import (
+	"context"
+	"dca-platform/pkg/calculator"
+	"dca-platform/pkg/catalog"
+	"dca-platform/pkg/finance"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// paramFlags acumula os -p nome=valor repetidos
+type paramFlags map[string]string
+
+func (p paramFlags) String() string { return fmt.Sprint(map[string]string(p)) }
+
+func (p paramFlags) Set(v string) error {
+	kv := strings.SplitN(v, "=", 2)
+	if len(kv) != 2 || kv[0] == "" {
+		return fmt.Errorf("use nome=valor")
+	}
+	p[kv[0]] = kv[1]
+	return nil
+}
+
+func main() {
+	params := paramFlags{}
+	list := flag.Bool("list", false, "lista as estratégias e seus parâmetros")
+	name := flag.String("strategy", "", "estratégia a executar (ver -list)")
+	symbol := flag.String("symbol", "", "ativo (ex: ^GSPC, FILE:minha-serie)")
+	start := flag.String("start", "2017-01-01", "data inicial (AAAA-MM-DD)")
+	end := flag.String("end", time.Now().Format("2006-01-02"), "data final (AAAA-MM-DD)")
+	currency := flag.String("currency", "", "moeda de relatório (vazio = moeda do ativo)")
+	flag.Var(params, "p", "parâmetro da estratégia nome=valor (repetível)")
+	flag.Parse()
+
+	if err := calculator.RegisterScripts(calculator.DefaultRegistry, "config/scripts"); err != nil {
+		fmt.Fprintf(os.Stderr, "Scripts de estratégia ignorados: %v\n", err)
+	}
+
+	if *list {
+		printStrategies()
+		return
+	}
+	if *name == "" || *symbol == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	startDate, err := time.Parse("2006-01-02", *start)
+	if err != nil {
+		fatalf("Data inicial inválida: %v", err)
+	}
+	endDate, err := time.Parse("2006-01-02", *end)
+	if err != nil {
+		fatalf("Data final inválida: %v", err)
+	}
+
+	// Valida os parâmetros antes de buscar as cotações
+	if _, ok := calculator.DefaultRegistry.Lookup(*name); !ok {
+		fatalf("Estratégia desconhecida: %s (use -list)", *name)
+	}
+	if _, _, err := calculator.DefaultRegistry.Validate(*name, params); err != nil {
+		fatalf("Parâmetros inválidos: %v", err)
+	}
+
+	// Mesmos provedores e mesmo cache do servidor (rodar da raiz do projeto)
+	ctx := context.Background()
+	client := finance.NewLocalClient("data", nil)
+	series, err := client.GetHistoricalDataIn(ctx, *symbol, startDate, endDate, strings.ToUpper(*currency))
+	if err != nil {
+		fatalf("Erro ao buscar %s: %v", *symbol, err)
+	}
+	market := calculator.ClientMarket(ctx, client, series, startDate, endDate)
+	if a, ok := catalog.New("config/assets.json").Lookup(*symbol); ok && a.Country != "" {
+		market.Country = a.Country
+	}
+	res, err := calculator.DefaultRegistry.Run(*name, market, params)
+	if err != nil {
+		fatalf("%v", err)
+	}
+	for _, notice := range res.Notices {
+		fmt.Fprintf(os.Stderr, "Aviso: %s\n", notice)
+	}
+
+	fmt.Printf("%s - %s (%s)\n", *symbol, res.StrategyName, series.Currency)
+	fmt.Printf("  Investido:     %.2f\n", res.TotalInvested)
+	fmt.Printf("  Valor final:   %.2f\n", res.FinalValue)
+	fmt.Printf("  Retorno:       %.2f%%\n", res.ReturnPercent)
+	if res.AnnualizedReturn != 0 {
+		fmt.Printf("  Retorno a.a.:  %.2f%%\n", res.AnnualizedReturn)
+	}
+	if res.CashBalance != 0 {
+		fmt.Printf("  Caixa:         %.2f\n", res.CashBalance)
+	}
+}
+
+// printStrategies mostra o nome, a descrição e o esquema de parâmetros de cada estratégia
+func printStrategies() {
+	for _, info := range calculator.DefaultRegistry.Describe() {
+		fmt.Printf("%s - %s\n", info.Name, info.Description)
+		for _, p := range info.Params {
+			line := fmt.Sprintf("  %-16s %-8s %s", p.Name, p.Type, p.Label)
+			if len(p.Choices) > 0 {
+				line += fmt.Sprintf(" [%s]", strings.Join(p.Choices, "|"))
+			}
+			if p.Default != "" {
+				line += fmt.Sprintf(" (padrão %s)", p.Default)
+			}
+			if p.Required {
+				line += " (obrigatório)"
+			}
+			fmt.Println(line)
+		}
+	}
+}
+
+func fatalf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, format+"\n", args...)
+	os.Exit(1)
+}
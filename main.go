@@ -2,65 +2,101 @@ package main
 
 import (
 	"dca-platform/pkg/calculator"
+	"dca-platform/pkg/catalog"
 	"dca-platform/pkg/finance"
 	"encoding/json"
 	"fmt"
 	"html/template"
 	"log"
 	"net/http"
+	"net/url"
 	"os"
+	"path/filepath"
 	"sort"
 	"strconv"
+	"strings"
 	"time"
 )
 
 
-// Estrutura para definir opções de ativos
-type AssetOption struct {
-	Symbol   string
-	Name     string
-	Category string
-}
+// assetCatalog lista os ativos do formulário com seus metadados; editar o arquivo dispensa reiniciar
+var assetCatalog = catalog.New("config/assets.json")
 
-// Lista Global de Ativos Suportados
-var SupportedAssets = []AssetOption{
-	// Cripto
-	{"BTC-USD", "Bitcoin (BTC)", "Cripto"},
-	{"ETH-USD", "Ethereum (ETH)", "Cripto"},
-	{"SOL-USD", "Solana (SOL)", "Cripto"},
-	
-	// Commodities / Índices
-	{"GC=F", "Ouro (Gold)", "Commodities"},
-	{"^GSPC", "S&P 500", "Indices"},
-	{"^IXIC", "Nasdaq 100", "Indices"},
-
-	// Brasil (ADRs)
-	{"EWZ", "iShares MSCI Brazil ETF", "Brasil"},
-	{"PBR", "Petrobras (PBR)", "Brasil"},
-	{"VALE", "Vale (VALE)", "Brasil"},
-	{"ITUB", "Itaú Unibanco (ITUB)", "Brasil"},
-	{"NU", "Nubank (NU)", "Brasil"},
-
-	// Renda Fixa BRL (Sintética USD)
-	{"FIXED-BRL-6.17", "Poupança BR (Est. 6.17% a.a.)", "Brasil RF"},
-	{"FIXED-BRL-10.0", "Tesouro Selic (Est. 10% a.a.)", "Brasil RF"},
-	{"FIXED-BRL-12.0", "CDB Pré (Est. 12% a.a.)", "Brasil RF"},
-
-	// USA Tech / Stocks
-	{"AAPL", "Apple (AAPL)", "EUA"},
-	{"MSFT", "Microsoft (MSFT)", "EUA"},
-	{"GOOGL", "Google (GOOGL)", "EUA"},
-	{"AMZN", "Amazon (AMZN)", "EUA"},
-	{"TSLA", "Tesla (TSLA)", "EUA"},
-	{"NVDA", "NVIDIA (NVDA)", "EUA"},
-	{"META", "Meta (Facebook)", "EUA"},
-}
+// coeCatalog lista os ativos objeto de COE (com seus tickers) e os modelos de COE oferecidos no formulário
+var coeCatalog = catalog.NewCOECatalog("config/coe.json")
+
+// issuerCatalog guarda a probabilidade de default e a recuperação de cada emissor de renda fixa e COE
+var issuerCatalog = catalog.NewIssuerCatalog("config/issuers.json")
 
 type COEConfig struct {
 	Asset         string
 	Protected     bool
 	Participation string // Keeping as string to preserve user input format
 	Cap           string // Keeping as string
+	Template      string `json:",omitempty"` // Modelo do catálogo de COEs que originou a configuração
+	Issuer        string `json:",omitempty"` // Emissor (ver config/issuers.json); COE não tem cobertura do FGC
+	TermMonths    int    `json:",omitempty"` // Prazo de cada COE (0 = janela inteira)
+	Rollover      string `json:",omitempty"` // Após o vencimento: "" novo COE, "fallback" ou "cash"
+	Fallback      string `json:",omitempty"` // Ativo alternativo do modo fallback
+
+	Payoff        string `json:",omitempty"` // "" ponta a ponta, "asian" ou "digital"
+	Strike        string `json:",omitempty"` // Digital: % do preço inicial
+	DigitalReturn string `json:",omitempty"` // Digital: % pago acima do strike
+
+	BarrierKind        string `json:",omitempty"` // "", "knock_out" ou "knock_in"
+	BarrierLevel       string `json:",omitempty"` // % do preço inicial
+	BarrierRebate      string `json:",omitempty"` // % pago no knock-out
+	BarrierObservation string `json:",omitempty"` // "" contínua ou "discrete"
+	BarrierMonths      int    `json:",omitempty"` // Intervalo das observações discretas (0 = mensal)
+
+	AutocallTrigger string `json:",omitempty"` // % do preço inicial que resgata antecipadamente ("" ou 0 = sem autocall)
+	AutocallCoupon  string `json:",omitempty"` // % pago por período decorrido no resgate antecipado
+	AutocallMonths  int    `json:",omitempty"` // Intervalo das observações do autocall
+	CouponRate      string `json:",omitempty"` // % pago a cada período ("" ou 0 = sem cupom)
+	CouponBarrier   string `json:",omitempty"` // % do preço inicial exigido para o cupom (0 = fixo)
+	CouponMonths    int    `json:",omitempty"` // Intervalo entre cupons
+	CouponMemory    bool   `json:",omitempty"` // Cupons condicionais não pagos acumulam
+}
+
+// params converte a configuração do formulário nos parâmetros da estratégia coe do registro.
+// amount 0 e rate vazia ficam de fora (padrão do esquema e sem valor justo).
+func (c COEConfig) params(amount float64, rate string) map[string]string {
+	values := map[string]string{
+		"protected":           strconv.FormatBool(c.Protected),
+		"participation":       c.Participation,
+		"cap":                 c.Cap,
+		"payoff":              choiceOr(c.Payoff, "point"),
+		"strike":              c.Strike,
+		"digital_return":      c.DigitalReturn,
+		"term_months":         strconv.Itoa(c.TermMonths),
+		"rollover":            choiceOr(c.Rollover, "renew"),
+		"fallback":            c.Fallback,
+		"barrier_kind":        choiceOr(c.BarrierKind, "none"),
+		"barrier_level":       c.BarrierLevel,
+		"barrier_rebate":      c.BarrierRebate,
+		"barrier_observation": choiceOr(c.BarrierObservation, "continuous"),
+		"barrier_months":      strconv.Itoa(c.BarrierMonths),
+		"autocall_trigger":    c.AutocallTrigger,
+		"autocall_coupon":     c.AutocallCoupon,
+		"autocall_months":     strconv.Itoa(c.AutocallMonths),
+		"coupon_rate":         c.CouponRate,
+		"coupon_barrier":      c.CouponBarrier,
+		"coupon_months":       strconv.Itoa(c.CouponMonths),
+		"coupon_memory":       strconv.FormatBool(c.CouponMemory),
+		"rate":                rate,
+	}
+	if amount > 0 {
+		values["amount"] = formatFloat(amount)
+	}
+	return values
+}
+
+// StrategyRun é uma estratégia do registro aplicada a um ativo.
+// Params vem no formato de query string (ex: "amount=1000&frequency=weekly").
+type StrategyRun struct {
+	Strategy string
+	Asset    string
+	Params   string `json:",omitempty"`
 }
 
 type PageData struct {
@@ -69,7 +105,7 @@ type PageData struct {
 	Amount        string
 	InitialAmount string
 	Frequency     string
-	Assets        []AssetOption
+	Assets        []catalog.Asset
 	
 	// Estado dos checkboxes
 	SelectedDCA   map[string]bool
@@ -81,18 +117,57 @@ type PageData struct {
 	CustomDCA         bool
 	CustomLS          bool
 
-	UseNative     bool // Novo campo
+	Currency      string   // Moeda de relatório ("" = moeda original de cada ativo)
+	Currencies    []string // Opções do seletor de moeda
+
+	// Aportes na moeda do investidor (ex: BRL em ativos cotados em USD)
+	ContribCurrency string
+	IOF             string
+	FXSpread        string
+	FXAlign         string // Estratégia de alinhamento de datas sem câmbio
+	BadTicks        string // Reparo de picos suspeitos (ver finance.RepairAction)
+
+	// Tratamento de proventos por tipo de estratégia (ver calculator.DividendMode)
+	DividendModeDCA string
+	DividendModeLS  string
+	Execution       string // Preço de execução das compras (ver calculator.ExecutionPrice)
+	Withholding     bool   // Aplicar retenção na fonte de residente no Brasil
+	JCPShare        string // % dos proventos de ações BR pagos como JCP
+	TradeCosts      bool   // Aplicar corretagem/taxas padrão do catálogo em cada compra
+	LevExpense      string // Taxa de administração dos ETFs alavancados LEV: (% a.a.)
+	LevBorrow       string // Custo de financiamento dos ETFs alavancados LEV: (% a.a.)
+	CreditDefault   string // Probabilidade anual de default (%) para todos os emissores, no lugar do cadastro
+	CreditRecovery  string // Recuperação no default (%) para todos os emissores, no lugar do cadastro
+	Overlay          string // Opções sobre cada Lump Sum: "", "covered_call" ou "protective_put"
+	OverlayMoneyness string // Strike em % do preço na rolagem
+	OverlayPremiums  string // "" reinvestir ou "cash"
+	OverlayRate      string // Taxa livre de risco do Black-Scholes (% a.a.)
 	
 	// Configurações COE
 	ShowCOE          bool
 	COEs             []COEConfig
 	COEsJSON         template.JS
+	COEUnderlyings   []catalog.COEUnderlying
+	COETemplates     []catalog.COETemplate
+	COETemplatesJSON template.JS
+	COERate          string // Taxa pré/CDI (% a.a.) do valor justo e da carteira replicante dos COEs
+	Issuers          []catalog.Issuer
 
+	// Estratégias do registro do calculador (calculator.DefaultRegistry) escolhidas no formulário
+	StrategyRuns     []StrategyRun
+	StrategyRunsJSON template.JS
+	StrategiesJSON   template.JS // Esquema de parâmetros de cada estratégia, para o formulário
+
+	Notices       []string          // Avisos sobre a qualidade/alinhamento dos dados
+	DataAsOf      map[string]string // Ativos servidos do cache local por falha do provedor: nome -> data
 	Results       []calculator.StrategyResult
 	BestStrategy  string
 	Error         string
 }
 
+// seriesCache guarda a última série de cada ativo para quando o Yahoo estiver fora do ar
+var seriesCache = finance.NewFileStore("data/cache")
+
 func main() {
 	// Debug: Imprimir diretório atual
 	dir, err := os.Getwd()
@@ -100,6 +175,18 @@ func main() {
 		log.Fatal(err)
 	}
 	fmt.Println("Diretório atual de execução:", dir)
+	if err := assetCatalog.Err(); err != nil {
+		log.Printf("Catálogo de ativos indisponível: %v", err)
+	}
+	if err := coeCatalog.Err(); err != nil {
+		log.Printf("Catálogo de COEs indisponível: %v", err)
+	}
+	if err := issuerCatalog.Err(); err != nil {
+		log.Printf("Cadastro de emissores indisponível: %v", err)
+	}
+	if err := calculator.RegisterScripts(calculator.DefaultRegistry, "config/scripts"); err != nil {
+		log.Printf("Scripts de estratégia ignorados: %v", err)
+	}
 
 	// Servir arquivos estáticos (CSS)
 	fs := http.FileServer(http.Dir("./static"))
@@ -107,6 +194,11 @@ func main() {
 
 	http.HandleFunc("/", handleHome)
 	http.HandleFunc("/simulate", handleSimulate)
+	http.HandleFunc("/api/simulate", handleAPISimulate)
+	http.HandleFunc("/api/symbols/search", handleSymbolSearch)
+	http.HandleFunc("/api/symbols/validate", handleSymbolValidate)
+	http.HandleFunc("/api/coe/products", handleCOEProducts)
+	http.HandleFunc("/api/strategies", handleStrategies)
 
 	fmt.Println("Servidor rodando em http://localhost:8080")
 	log.Fatal(http.ListenAndServe(":8080", nil))
@@ -118,7 +210,22 @@ func handleHome(w http.ResponseWriter, r *http.Request) {
 		EndDate:   time.Now().Format("2006-01-02"),
 		Amount:    "100",
 		Frequency: "monthly",
-		Assets:    SupportedAssets,
+		Currency:  "USD",
+		FXAlign:   string(finance.AlignForwardFill),
+		BadTicks:  string(finance.RepairKeep),
+		Currencies: finance.SupportedCurrencies,
+		Assets:    assetCatalog.Assets(),
+		COEUnderlyings:   coeCatalog.Underlyings(),
+		Issuers:          issuerCatalog.Issuers(),
+		COETemplates:     coeCatalog.Templates(),
+		COETemplatesJSON: coeTemplatesJSON(),
+		StrategiesJSON:   strategiesJSON(),
+		StrategyRunsJSON: "[]",
+		CustomTickersJSON: "[]",
+		COEsJSON:         "[]",
+		COERate:          "10",
+		OverlayMoneyness: "105",
+		OverlayRate:      "10",
 		SelectedDCA: map[string]bool{
 			"BTC-USD": true,
 		},
@@ -135,6 +242,154 @@ func handleSimulate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	renderTemplate(w, runSimulation(r))
+}
+
+// SimulationResponse é o corpo JSON de /api/simulate
+type SimulationResponse struct {
+	Results      []calculator.StrategyResult `json:"results"`
+	BestStrategy string                      `json:"best_strategy,omitempty"`
+	Notices      []string                    `json:"notices,omitempty"`
+	DataAsOf     map[string]string           `json:"data_as_of,omitempty"` // Ativos servidos do cache local: símbolo -> data
+	Error        string                      `json:"error,omitempty"`
+}
+
+// handleAPISimulate executa a mesma simulação do formulário e devolve JSON.
+// Aceita os mesmos parâmetros do formulário, via query string ou corpo POST.
+func handleAPISimulate(w http.ResponseWriter, r *http.Request) {
+	data := runSimulation(r)
+
+	w.Header().Set("Content-Type", "application/json")
+	if data.Error != "" && len(data.Results) == 0 {
+		w.WriteHeader(http.StatusBadRequest)
+	}
+	json.NewEncoder(w).Encode(SimulationResponse{
+		Results:      data.Results,
+		BestStrategy: data.BestStrategy,
+		Notices:      data.Notices,
+		DataAsOf:     data.DataAsOf,
+		Error:        data.Error,
+	})
+}
+
+// SymbolResult é um item da busca de símbolos
+type SymbolResult struct {
+	Symbol   string `json:"symbol"`
+	Name     string `json:"name"`
+	Category string `json:"category,omitempty"`
+	Currency string `json:"currency,omitempty"`
+	Source   string `json:"source"` // catalog, suggestion ou provider
+}
+
+// SymbolSearchResponse é o corpo JSON de /api/symbols/search
+type SymbolSearchResponse struct {
+	Query   string         `json:"query"`
+	Results []SymbolResult `json:"results"`
+	Error   string         `json:"error,omitempty"` // Falha na busca do provedor (os resultados do catálogo continuam valendo)
+}
+
+// handleSymbolSearch busca símbolos no catálogo e, com provider=1, também no provedor de cotações
+func handleSymbolSearch(w http.ResponseWriter, r *http.Request) {
+	query := strings.TrimSpace(r.FormValue("q"))
+	resp := SymbolSearchResponse{Query: query, Results: []SymbolResult{}}
+	seen := make(map[string]bool)
+	add := func(res SymbolResult) {
+		if !seen[strings.ToUpper(res.Symbol)] {
+			seen[strings.ToUpper(res.Symbol)] = true
+			resp.Results = append(resp.Results, res)
+		}
+	}
+
+	if query != "" {
+		for _, a := range assetCatalog.Search(query) {
+			add(SymbolResult{Symbol: a.Symbol, Name: a.Name, Category: a.Category, Currency: a.Currency, Source: "catalog"})
+		}
+		for _, alt := range finance.SuggestSymbols(query) {
+			if strings.EqualFold(alt, query) {
+				continue
+			}
+			add(SymbolResult{Symbol: alt, Name: alt, Source: "suggestion"})
+		}
+		if r.FormValue("provider") == "1" {
+			matches, err := newClient().SearchSymbols(r.Context(), query)
+			if err != nil {
+				resp.Error = err.Error()
+			}
+			for _, m := range matches {
+				add(SymbolResult{Symbol: m.Symbol, Name: m.Name, Category: m.Exchange, Source: "provider"})
+			}
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// handleSymbolValidate verifica se um símbolo tem cotações entre startDate e endDate (padrão: últimos 12 meses)
+func handleSymbolValidate(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	symbol := strings.TrimSpace(r.FormValue("symbol"))
+	if symbol == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(finance.SymbolCheck{Error: "informe o parâmetro symbol"})
+		return
+	}
+
+	endDate := time.Now()
+	startDate := endDate.AddDate(-1, 0, 0)
+	if v := r.FormValue("startDate"); v != "" {
+		d, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(finance.SymbolCheck{Symbol: symbol, Error: "data inicial inválida"})
+			return
+		}
+		startDate = d
+	}
+	if v := r.FormValue("endDate"); v != "" {
+		d, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(finance.SymbolCheck{Symbol: symbol, Error: "data final inválida"})
+			return
+		}
+		endDate = d
+	}
+
+	json.NewEncoder(w).Encode(newClient().CheckSymbol(r.Context(), symbol, startDate, endDate))
+}
+
+// COEProductsResponse é o corpo JSON de /api/coe/products
+type COEProductsResponse struct {
+	Underlyings []catalog.COEUnderlying `json:"underlyings"`
+	Templates   []catalog.COETemplate   `json:"templates"`
+}
+
+// handleCOEProducts lista os ativos objeto e os modelos de COE aceitos em coe_asset e coe_template
+func handleCOEProducts(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(COEProductsResponse{
+		Underlyings: coeCatalog.Underlyings(),
+		Templates:   coeCatalog.Templates(),
+	})
+}
+
+// handleStrategies lista as estratégias do registro com o esquema de parâmetros aceito em strategy_params
+func handleStrategies(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(calculator.DefaultRegistry.Describe())
+}
+
+// newClient cria o cliente de cotações de uma requisição, com cache local e os provedores de símbolos com prefixo
+// (FILE: em data/prices, CVM: em data/cvm, cestas de data/baskets.json e LEV:)
+func newClient() *finance.Client {
+	client := finance.NewLocalClient("data", seriesCache)
+	client.Memoize = true
+	return client
+}
+
+// runSimulation lê os parâmetros da requisição e calcula todas as estratégias selecionadas
+func runSimulation(r *http.Request) PageData {
 	startDateStr := r.FormValue("startDate")
 	endDateStr := r.FormValue("endDate")
 	amountStr := r.FormValue("amount")
@@ -151,39 +406,256 @@ func handleSimulate(w http.ResponseWriter, r *http.Request) {
 	customDCA := r.FormValue("custom_dca") == "on"
 	customLS := r.FormValue("custom_ls") == "on"
 	
-	useNative := r.FormValue("use_native") == "true"
+	// Moeda de relatório: USD quando o campo não é enviado, como antes do seletor de moeda;
+	// vazio (moeda original) só quando pedido explicitamente ou pelo antigo checkbox use_native.
+	currency := "USD"
+	if _, ok := r.Form["currency"]; ok {
+		currency = r.FormValue("currency")
+	}
+	if r.FormValue("use_native") == "true" {
+		currency = ""
+	}
+
+	// Moeda dos aportes ("" = mesma moeda do ativo) e custos de remessa em %
+	contribCurrency := r.FormValue("contrib_currency")
+	iofStr := r.FormValue("iof")
+	spreadStr := r.FormValue("fx_spread")
+	dcaDivMode := calculator.DividendMode(r.FormValue("dividend_mode_dca"))
+	lsDivMode := calculator.DividendMode(r.FormValue("dividend_mode_ls"))
+	execution := calculator.ExecutionPrice(r.FormValue("execution"))
+	withholding := r.FormValue("withholding") == "on"
+	tradeCosts := r.FormValue("trade_costs") == "on"
+	jcpShareStr := r.FormValue("jcp_share")
+	creditDefaultStr := r.FormValue("credit_default")
+	creditRecoveryStr := r.FormValue("credit_recovery")
+	overlayStr := r.FormValue("overlay")
+	overlayMoneynessStr := r.FormValue("overlay_moneyness")
+	overlayPremiumsStr := r.FormValue("overlay_premiums")
+	overlayRateStr := r.FormValue("overlay_rate")
+	levExpenseStr := r.FormValue("lev_expense")
+	levBorrowStr := r.FormValue("lev_borrow")
+	badTicks := r.FormValue("bad_ticks")
+	switch finance.RepairAction(badTicks) {
+	case "":
+		badTicks = string(finance.RepairKeep)
+	case finance.RepairKeep, finance.RepairDrop, finance.RepairFill:
+	default:
+		return errorPage(fmt.Sprintf("Reparo de picos desconhecido: %s", badTicks))
+	}
+	fxAlign := r.FormValue("fx_align")
+	switch finance.AlignStrategy(fxAlign) {
+	case "":
+		fxAlign = string(finance.AlignForwardFill)
+	case finance.AlignInner, finance.AlignForwardFill, finance.AlignInterpolate:
+	default:
+		return errorPage(fmt.Sprintf("Alinhamento de câmbio desconhecido: %s", fxAlign))
+	}
 	
+	// Estratégias do registro: listas paralelas strategy, strategy_asset e strategy_params
+	var strategyRuns []StrategyRun
+	strategyAssets := r.Form["strategy_asset"]
+	strategyParams := r.Form["strategy_params"]
+	for i, name := range r.Form["strategy"] {
+		run := StrategyRun{Strategy: name, Asset: strings.TrimSpace(formAt(strategyAssets, i)), Params: formAt(strategyParams, i)}
+		if run.Strategy == "" || run.Asset == "" {
+			continue
+		}
+		if _, ok := calculator.DefaultRegistry.Lookup(run.Strategy); !ok {
+			return errorPage(fmt.Sprintf("Estratégia desconhecida: %s", run.Strategy))
+		}
+		values, err := strategyParamValues(run.Params)
+		if err == nil {
+			_, _, err = calculator.DefaultRegistry.Validate(run.Strategy, values)
+		}
+		if err != nil {
+			return errorPage(fmt.Sprintf("Parâmetros de %s inválidos: %v", run.Strategy, err))
+		}
+		strategyRuns = append(strategyRuns, run)
+	}
+
 	// COE Parsing - Múltiplos
 	coeEnabled := r.FormValue("coe_enabled") == "on"
+	coeRateStr := r.FormValue("coe_rate")
 	
-	// Recuperar slices do form
+	// Recuperar slices do form. Cada COE vem de um modelo do catálogo (coe_template),
+	// dos campos avulsos, ou dos dois (campos preenchidos sobrepõem o modelo).
 	coeAssets := r.Form["coe_asset"]
 	coeProtectedList := r.Form["coe_protected"]
 	coePartList := r.Form["coe_participation"]
 	coeCapList := r.Form["coe_cap"]
-	
+	coeTemplateList := r.Form["coe_template"]
+	coeIssuerList := r.Form["coe_issuer"]
+	coeTermList := r.Form["coe_term"]
+	coeRolloverList := r.Form["coe_rollover"]
+	coeFallbackList := r.Form["coe_fallback"]
+	coeBarrierList := r.Form["coe_barrier"]
+	coeBarrierLevelList := r.Form["coe_barrier_level"]
+	coeRebateList := r.Form["coe_rebate"]
+	coeObservationList := r.Form["coe_observation"]
+	coeBarrierMonthsList := r.Form["coe_barrier_months"]
+	coePayoffList := r.Form["coe_payoff"]
+	coeStrikeList := r.Form["coe_strike"]
+	coeDigitalList := r.Form["coe_digital_return"]
+	coeAutocallTriggerList := r.Form["coe_autocall_trigger"]
+	coeAutocallCouponList := r.Form["coe_autocall_coupon"]
+	coeAutocallMonthsList := r.Form["coe_autocall_months"]
+	coeCouponRateList := r.Form["coe_coupon_rate"]
+	coeCouponBarrierList := r.Form["coe_coupon_barrier"]
+	coeCouponMonthsList := r.Form["coe_coupon_months"]
+	coeCouponMemoryList := r.Form["coe_coupon_memory"]
+
 	var coes []COEConfig
-	
-	// Assumindo que o JS garante sincronia de indices via hidden inputs
-	if coeEnabled && len(coeAssets) > 0 {
-		for i, asset := range coeAssets {
-			if i >= len(coeProtectedList) || i >= len(coePartList) || i >= len(coeCapList) {
+	if coeEnabled {
+		count := len(coeAssets)
+		if len(coeTemplateList) > count {
+			count = len(coeTemplateList)
+		}
+		for i := 0; i < count; i++ {
+			var coe COEConfig
+			if id := formAt(coeTemplateList, i); id != "" {
+				tmpl, ok := coeCatalog.Template(id)
+				if !ok {
+					return errorPage(fmt.Sprintf("Modelo de COE desconhecido: %s", id))
+				}
+				coe = COEConfig{
+					Asset:         tmpl.Underlying,
+					Protected:     tmpl.Protected,
+					Participation: strconv.FormatFloat(tmpl.Participation, 'f', -1, 64),
+					Cap:           strconv.FormatFloat(tmpl.Cap, 'f', -1, 64),
+					Template:      tmpl.ID,
+					Issuer:        tmpl.Issuer,
+					TermMonths:    tmpl.TermMonths,
+					Payoff:        string(tmpl.Payoff),
+				}
+				if tmpl.Payoff == calculator.PayoffDigital {
+					coe.Strike = strconv.FormatFloat(tmpl.Strike, 'f', -1, 64)
+					coe.DigitalReturn = strconv.FormatFloat(tmpl.DigitalReturn, 'f', -1, 64)
+				}
+				if b := tmpl.Barrier; b != nil {
+					coe.BarrierKind = string(b.Kind)
+					coe.BarrierLevel = strconv.FormatFloat(b.Level, 'f', -1, 64)
+					coe.BarrierRebate = strconv.FormatFloat(b.Rebate, 'f', -1, 64)
+					coe.BarrierObservation = string(b.Observation)
+					coe.BarrierMonths = b.ObservationMonths
+				}
+				if a := tmpl.Autocall; a != nil {
+					coe.AutocallTrigger = strconv.FormatFloat(a.Trigger, 'f', -1, 64)
+					coe.AutocallCoupon = strconv.FormatFloat(a.Coupon, 'f', -1, 64)
+					coe.AutocallMonths = a.ObservationMonths
+				}
+				if c := tmpl.Coupon; c != nil {
+					coe.CouponRate = strconv.FormatFloat(c.Rate, 'f', -1, 64)
+					coe.CouponBarrier = strconv.FormatFloat(c.Barrier, 'f', -1, 64)
+					coe.CouponMonths = c.ObservationMonths
+					coe.CouponMemory = c.Memory
+				}
+			} else if formAt(coePartList, i) == "" || formAt(coeCapList, i) == "" {
+				// Sem modelo, os campos avulsos são obrigatórios
 				continue
 			}
-			
-			prot := coeProtectedList[i] == "true"
-			part := coePartList[i]
-			capVal := coeCapList[i]
-			
-			coes = append(coes, COEConfig{
-				Asset:         asset,
-				Protected:     prot,
-				Participation: part,
-				Cap:           capVal,
-			})
+
+			if v := formAt(coeAssets, i); v != "" {
+				coe.Asset = v
+			}
+			if v := formAt(coeProtectedList, i); v != "" {
+				coe.Protected = v == "true"
+			}
+			if v := formAt(coePartList, i); v != "" {
+				coe.Participation = v
+			}
+			if v := formAt(coeCapList, i); v != "" {
+				coe.Cap = v
+			}
+			if v := formAt(coeTermList, i); v != "" {
+				term, err := strconv.Atoi(v)
+				if err != nil || term < 0 {
+					return errorPage("Prazo do COE inválido.")
+				}
+				coe.TermMonths = term
+			}
+			if v := formAt(coePayoffList, i); v != "" {
+				coe.Payoff = v
+				coe.Strike = formAt(coeStrikeList, i)
+				coe.DigitalReturn = formAt(coeDigitalList, i)
+			}
+			if coe.Payoff == "point" {
+				// Ponta a ponta explícito, para substituir o payoff de um modelo
+				coe.Payoff = ""
+			}
+			if v := formAt(coeIssuerList, i); v != "" {
+				coe.Issuer = v
+			}
+			coe.Rollover = formAt(coeRolloverList, i)
+			coe.Fallback = formAt(coeFallbackList, i)
+			if v := formAt(coeBarrierList, i); v != "" {
+				coe.BarrierKind = v
+				coe.BarrierLevel = formAt(coeBarrierLevelList, i)
+				coe.BarrierRebate = formAt(coeRebateList, i)
+				coe.BarrierObservation = formAt(coeObservationList, i)
+				months, err := optionalMonths(formAt(coeBarrierMonthsList, i))
+				if err != nil {
+					return errorPage("Intervalo das observações da barreira do COE inválido.")
+				}
+				coe.BarrierMonths = months
+			}
+			if coe.BarrierKind == "none" {
+				coe.BarrierKind = ""
+			}
+			if v := formAt(coeAutocallTriggerList, i); v != "" {
+				coe.AutocallTrigger = v
+				coe.AutocallCoupon = formAt(coeAutocallCouponList, i)
+				months, err := optionalMonths(formAt(coeAutocallMonthsList, i))
+				if err != nil {
+					return errorPage("Intervalo do autocall do COE inválido.")
+				}
+				coe.AutocallMonths = months
+			}
+			if v := formAt(coeCouponRateList, i); v != "" {
+				coe.CouponRate = v
+				coe.CouponBarrier = formAt(coeCouponBarrierList, i)
+				coe.CouponMemory = formAt(coeCouponMemoryList, i) == "true"
+				months, err := optionalMonths(formAt(coeCouponMonthsList, i))
+				if err != nil {
+					return errorPage("Intervalo dos cupons do COE inválido.")
+				}
+				coe.CouponMonths = months
+			}
+			if coe.Asset == "" {
+				continue
+			}
+			// Tipos, faixas e combinações são conferidos pelo esquema da estratégia coe do registro
+			if _, _, err := calculator.DefaultRegistry.Validate("coe", coe.params(0, coeRateStr)); err != nil {
+				return errorPage(fmt.Sprintf("COE %s inválido: %v", coe.Asset, err))
+			}
+			coes = append(coes, coe)
 		}
 	}
-	
+
+	// Série enviada pelo formulário (CSV/JSON) entra como ativo personalizado
+	// Regras de qualidade da requisição, aplicadas às séries dos provedores e ao arquivo enviado
+	qualityRules := finance.DefaultQualityRules()
+	qualityRules.Jumps = finance.RepairAction(badTicks)
+
+	upload, uploadSymbol, err := readUpload(r, qualityRules)
+	if err != nil {
+		return errorPage("Arquivo enviado inválido: " + err.Error())
+	}
+	if uploadSymbol != "" {
+		if customDCA {
+			dcaAssets = append(dcaAssets, uploadSymbol)
+		}
+		if customLS {
+			lsAssets = append(lsAssets, uploadSymbol)
+		}
+		used := customDCA || customLS
+		for _, run := range strategyRuns {
+			used = used || strings.EqualFold(run.Asset, uploadSymbol)
+		}
+		if !used {
+			return errorPage(fmt.Sprintf("Arquivo enviado sem estratégia: marque DCA ou Lump Sum no ativo personalizado, ou use %s em uma estratégia do registro.", uploadSymbol))
+		}
+	}
+
 	// Adicionar ativos customizados às listas se selecionado
 	for _, ticker := range customTickers {
 		if ticker == "" { continue }
@@ -198,28 +670,101 @@ func handleSimulate(w http.ResponseWriter, r *http.Request) {
 	// Validar inputs
 	startDate, err := time.Parse("2006-01-02", startDateStr)
 	if err != nil {
-		renderError(w, "Data de início inválida.")
-		return
+		return errorPage("Data de início inválida.")
 	}
 	endDate, err := time.Parse("2006-01-02", endDateStr)
 	if err != nil {
-		renderError(w, "Data de fim inválida.")
-		return
+		return errorPage("Data de fim inválida.")
 	}
-	amount, err := strconv.ParseFloat(amountStr, 64)
-	if err != nil {
-		renderError(w, "Valor recorrente inválido.")
-		return
+	if _, err := strconv.ParseFloat(amountStr, 64); err != nil {
+		return errorPage("Valor recorrente inválido.")
 	}
 	
 	var initialAmount float64
 	if initialAmountStr != "" {
 		initialAmount, err = strconv.ParseFloat(initialAmountStr, 64)
 		if err != nil {
-			renderError(w, "Valor inicial inválido.")
-			return
+			return errorPage("Valor inicial inválido.")
+		}
+	}
+
+	// Custos dos ETFs alavancados sintéticos (LEV:<fator>:<ativo>)
+	leveraged := &finance.LeveragedProvider{
+		ExpenseRatio: finance.DefaultLeveragedExpenseRatio,
+		BorrowRate:   finance.DefaultLeveragedBorrowRate,
+	}
+	if levExpenseStr != "" {
+		leveraged.ExpenseRatio, err = strconv.ParseFloat(levExpenseStr, 64)
+		if err != nil {
+			return errorPage("Taxa do ETF alavancado inválida.")
+		}
+		leveraged.ExpenseRatio /= 100.0
+	}
+	if levBorrowStr != "" {
+		leveraged.BorrowRate, err = strconv.ParseFloat(levBorrowStr, 64)
+		if err != nil {
+			return errorPage("Custo de financiamento inválido.")
+		}
+		leveraged.BorrowRate /= 100.0
+	}
+
+	// Risco de crédito informado no formulário substitui o cadastro de emissores
+	var creditDefault, creditRecovery float64
+	if creditDefaultStr != "" {
+		creditDefault, err = strconv.ParseFloat(creditDefaultStr, 64)
+		if err != nil || creditDefault < 0 || creditDefault >= 100 {
+			return errorPage("Probabilidade de default inválida.")
 		}
 	}
+	if creditRecoveryStr != "" {
+		creditRecovery, err = strconv.ParseFloat(creditRecoveryStr, 64)
+		if err != nil || creditRecovery < 0 || creditRecovery > 100 {
+			return errorPage("Taxa de recuperação inválida.")
+		}
+	}
+
+	// Opções sobre a posição de cada Lump Sum, precificadas por Black-Scholes (options_overlay do registro)
+	var overlayValues map[string]string
+	if overlayStr != "" {
+		overlayValues = map[string]string{
+			"kind":      overlayStr,
+			"moneyness": overlayMoneynessStr,
+			"premiums":  choiceOr(overlayPremiumsStr, "reinvest"),
+			"rate":      choiceOr(overlayRateStr, "0"),
+		}
+		if _, _, err := calculator.DefaultRegistry.Validate("options_overlay", overlayValues); err != nil {
+			return errorPage(fmt.Sprintf("Opções sobre a posição inválidas: %v", err))
+		}
+	}
+
+	// positionValues monta os parâmetros das compras (dca e lump_sum do registro) com as opções do formulário
+	positionValues := func(symbol string, mode calculator.DividendMode) map[string]string {
+		costs := tradeCostsFor(symbol, tradeCosts)
+		return map[string]string{
+			"dividend_mode": choiceOr(string(mode), "ignore"),
+			"execution":     choiceOr(string(execution), "close"),
+			"withholding":   strconv.FormatBool(withholding),
+			"jcp_share":     jcpShareStr,
+			"brokerage":     formatFloat(costs.Brokerage),
+			"fee":           formatFloat(costs.Fee * 100),
+		}
+	}
+	dcaValues := func(symbol string) map[string]string {
+		values := positionValues(symbol, dcaDivMode)
+		values["initial_amount"] = initialAmountStr
+		values["amount"] = amountStr
+		values["frequency"] = freqStr
+		values["contribution_currency"] = contribCurrency
+		values["iof"] = iofStr
+		values["fx_spread"] = spreadStr
+		return values
+	}
+	if _, _, err := calculator.DefaultRegistry.Validate("dca", dcaValues("")); err != nil {
+		return errorPage(fmt.Sprintf("Opções do DCA inválidas: %v", err))
+	}
+	if _, _, err := calculator.DefaultRegistry.Validate("lump_sum", positionValues("", lsDivMode)); err != nil {
+		return errorPage(fmt.Sprintf("Opções do Lump Sum inválidas: %v", err))
+	}
 
 	// Reconstruir mapas de seleção
 	selDca := make(map[string]bool)
@@ -232,6 +777,7 @@ func handleSimulate(w http.ResponseWriter, r *http.Request) {
 	jsonBytes, _ := json.Marshal(customTickers)
 	// Serializar COEs para JS
 	coesBytes, _ := json.Marshal(coes)
+	runsBytes, _ := json.Marshal(strategyRuns)
 	
 	data := PageData{
 		StartDate:    startDateStr,
@@ -239,26 +785,83 @@ func handleSimulate(w http.ResponseWriter, r *http.Request) {
 		Amount:       amountStr,
 		InitialAmount: initialAmountStr,
 		Frequency:    freqStr,
-		Assets:       SupportedAssets,
+		Assets:       assetCatalog.Assets(),
 		SelectedDCA:  selDca,
 		SelectedLS:   selLs,
 		CustomTickers: customTickers,
 		CustomTickersJSON: template.JS(jsonBytes),
 		CustomDCA:    customDCA,
 		CustomLS:     customLS,
-		UseNative:    useNative,
+		Currency:     currency,
+		Currencies:   finance.SupportedCurrencies,
+		ContribCurrency: contribCurrency,
+		IOF:          iofStr,
+		FXSpread:     spreadStr,
+		FXAlign:      fxAlign,
+		BadTicks:     badTicks,
+		DividendModeDCA: string(dcaDivMode),
+		DividendModeLS:  string(lsDivMode),
+		Execution:       string(execution),
+		Withholding:     withholding,
+		JCPShare:        jcpShareStr,
+		TradeCosts:      tradeCosts,
+		LevExpense:      levExpenseStr,
+		LevBorrow:       levBorrowStr,
+		CreditDefault:   creditDefaultStr,
+		CreditRecovery:  creditRecoveryStr,
+		Overlay:          overlayStr,
+		OverlayMoneyness: overlayMoneynessStr,
+		OverlayPremiums:  overlayPremiumsStr,
+		OverlayRate:      overlayRateStr,
 		ShowCOE:      coeEnabled,
 		COEs:         coes,
 		COEsJSON:     template.JS(coesBytes),
+		StrategyRuns:      strategyRuns,
+		StrategyRunsJSON:  template.JS(runsBytes),
+		COEUnderlyings:   coeCatalog.Underlyings(),
+		Issuers:          issuerCatalog.Issuers(),
+		COETemplates:     coeCatalog.Templates(),
+		COETemplatesJSON: coeTemplatesJSON(),
+		StrategiesJSON:    strategiesJSON(),
+		COERate:          coeRateStr,
 	}
 
-	if len(dcaAssets) == 0 && len(lsAssets) == 0 {
+	if len(dcaAssets) == 0 && len(lsAssets) == 0 && len(strategyRuns) == 0 {
 		data.Error = "Selecione pelo menos um ativo (DCA ou Lump Sum)."
-		renderTemplate(w, data)
-		return
+		return data
+	}
+
+	// Chamadas ao provedor são canceladas se o usuário abandonar a requisição
+	ctx := r.Context()
+	client := newClient()
+	client.RegisterProvider("LEV", leveraged)
+	if upload != nil {
+		// Série enviada pelo formulário vale só para esta simulação
+		client.RegisterProvider("UPLOAD", upload)
+	}
+	client.FXAlign = finance.AlignStrategy(fxAlign)
+	client.Quality = qualityRules
+
+	// Ativos digitados livremente são validados antes da simulação, com sugestões de grafia
+	if customDCA || customLS {
+		var invalid []string
+		for _, ticker := range customTickers {
+			check := client.CheckSymbol(ctx, ticker, startDate, endDate)
+			if check.Valid {
+				continue
+			}
+			msg := fmt.Sprintf("%s (%s)", ticker, check.Error)
+			if len(check.Suggestions) > 0 {
+				msg = fmt.Sprintf("%s - você quis dizer %s?", msg, strings.Join(check.Suggestions, ", "))
+			}
+			invalid = append(invalid, msg)
+		}
+		if len(invalid) > 0 {
+			data.Error = "Ativos sem dados no período: " + strings.Join(invalid, "; ")
+			return data
+		}
 	}
 
-	client := finance.NewClient()
 	var results []calculator.StrategyResult
 
 	// Precisamos saber o TotalInvested padrão para o Lump Sum
@@ -274,31 +877,99 @@ func handleSimulate(w http.ResponseWriter, r *http.Request) {
 	var theoreticalTotalInvested float64 = 0
 	calculatedTotal := false
 
-	var freq calculator.Frequency
-	switch freqStr {
-	case "daily":
-		freq = calculator.Daily
-	case "weekly":
-		freq = calculator.Weekly
-	default:
-		freq = calculator.Monthly
+	// Câmbios já buscados nesta simulação, por par (moeda do ativo -> moeda dos aportes)
+	fxCache := make(map[string][]finance.Quote)
+	getFXRates := func(from, to string) ([]finance.Quote, error) {
+		key := from + "/" + to
+		if rates, ok := fxCache[key]; ok {
+			return rates, nil
+		}
+		fx, err := client.GetFXRates(ctx, from, to, startDate, endDate)
+		if err != nil {
+			return nil, err
+		}
+		fxCache[key] = fx.Quotes
+		return fx.Quotes, nil
+	}
+
+	// market dá às estratégias do registro a série do ativo, o país do emissor e acesso a outras séries e câmbios
+	market := func(symbol string, series finance.Series) calculator.Market {
+		m := calculator.ClientMarket(ctx, client, series, startDate, endDate)
+		m.Country = issuerCountry(symbol, series)
+		m.FX = getFXRates
+		return m
+	}
+
+	// fgcLimitIn devolve a garantia do FGC convertida para a moeda do resultado (0 sem câmbio)
+	fgcLimitIn := func(cur string) float64 {
+		if cur == "" || cur == "BRL" {
+			return calculator.FGCLimit
+		}
+		rates, err := getFXRates("BRL", cur)
+		if err != nil || len(rates) == 0 {
+			return 0
+		}
+		return calculator.FGCLimit * rates[len(rates)-1].Close
+	}
+
+	// applyCredit ajusta o resultado ao risco de crédito do emissor e avisa quando o saldo
+	// passa da garantia do FGC. Emissores sem cadastro só são ajustados com a probabilidade do formulário.
+	applyCredit := func(res calculator.StrategyResult, issuer string, fgc bool, cur string) calculator.StrategyResult {
+		if issuer == "" {
+			return res
+		}
+		risk := calculator.CreditRisk{Issuer: issuer}
+		info, known := issuerCatalog.Lookup(issuer)
+		if known {
+			risk.DefaultProbability = info.DefaultProbability / 100.0
+			risk.Recovery = info.Recovery / 100.0
+		}
+		if creditDefaultStr != "" {
+			risk.DefaultProbability = creditDefault / 100.0
+		}
+		if creditRecoveryStr != "" {
+			risk.Recovery = creditRecovery / 100.0
+		}
+		if fgc {
+			risk.Guaranteed = fgcLimitIn(cur)
+			if notice := risk.FGCNotice(res, cur); notice != "" {
+				data.Notices = append(data.Notices, notice)
+			}
+		}
+		if !known && creditDefaultStr == "" {
+			res.Issuer = issuer
+			return res
+		}
+		return calculator.ApplyCreditRisk(res, risk, startDate, endDate)
 	}
 
 	// Processar DCA Assets
 	for _, symbol := range dcaAssets {
-		histData, err := client.GetHistoricalData(symbol, startDate, endDate, useNative)
+		series, err := client.GetHistoricalDataIn(ctx, symbol, startDate, endDate, currency)
 		if err != nil {
 			fmt.Printf("Erro dados %s: %v\n", symbol, err)
 			continue
 		}
+		addSeriesNotices(&data, series)
 		
-		dcaRes := calculator.CalculateDCA(histData, initialAmount, amount, freq)
+		dcaRes, err := calculator.DefaultRegistry.Run("dca", market(symbol, series), dcaValues(symbol))
+		if err != nil {
+			fmt.Printf("Erro DCA %s: %v\n", symbol, err)
+			data.Notices = append(data.Notices, fmt.Sprintf("DCA %s: %v", getAssetName(symbol), err))
+			continue
+		}
 		dcaRes.StrategyName = fmt.Sprintf("DCA %s", getAssetName(symbol))
+		if dcaRes.LocalCurrency != "" {
+			dcaRes.StrategyName = fmt.Sprintf("%s (aportes em %s)", dcaRes.StrategyName, dcaRes.LocalCurrency)
+		}
 		if initialAmount > 0 {
 			// Se tem aporte inicial, sobrescreve o nome que veio do calculador para incluir o nome do ativo
 			dcaRes.StrategyName = fmt.Sprintf("%s (%s)", dcaRes.StrategyName, getAssetName(symbol))
 		}
 		
+		if a, ok := assetCatalog.Lookup(symbol); ok {
+			dcaRes = applyCredit(dcaRes, a.Issuer, a.FGC, series.Currency)
+		}
 		results = append(results, dcaRes)
 		
 		if !calculatedTotal {
@@ -311,61 +982,142 @@ func handleSimulate(w http.ResponseWriter, r *http.Request) {
 	// Vamos pegar dados do primeiro ativo LS para ter o calendário.
 	if !calculatedTotal && len(lsAssets) > 0 {
 		// Pegar dados do primeiro LS para calcular as datas
-		histData, err := client.GetHistoricalData(lsAssets[0], startDate, endDate, useNative)
+		series, err := client.GetHistoricalDataIn(ctx, lsAssets[0], startDate, endDate, currency)
 		if err == nil {
 			// Simular DCA fantasma só para pegar o valor investido
-			dummy := calculator.CalculateDCA(histData, initialAmount, amount, freq)
-			theoreticalTotalInvested = dummy.TotalInvested
-			calculatedTotal = true
+			dummy, err := calculator.DefaultRegistry.Run("dca", calculator.Market{Quotes: series.Quotes}, map[string]string{
+				"initial_amount": initialAmountStr,
+				"amount":         amountStr,
+				"frequency":      freqStr,
+			})
+			if err == nil {
+				theoreticalTotalInvested = dummy.TotalInvested
+				calculatedTotal = true
+			}
 		}
 	}
 
 	// Processar Lump Sum Assets
 	for _, symbol := range lsAssets {
-		histData, err := client.GetHistoricalData(symbol, startDate, endDate, useNative)
+		series, err := client.GetHistoricalDataIn(ctx, symbol, startDate, endDate, currency)
 		if err != nil {
 			fmt.Printf("Erro dados %s: %v\n", symbol, err)
 			continue
 		}
+		if !selDca[symbol] {
+			addSeriesNotices(&data, series)
+		}
 		
 		// Lump Sum assume investir TUDO no início.
 		// Qual valor? O mesmo que seria gasto no DCA (theoreticalTotalInvested).
-		lsRes := calculator.CalculateLumpSum(histData, theoreticalTotalInvested, fmt.Sprintf("Lump Sum %s", getAssetName(symbol)))
+		m := market(symbol, series)
+		values := positionValues(symbol, lsDivMode)
+		values["amount"] = formatFloat(theoreticalTotalInvested)
+		lsRes, err := calculator.DefaultRegistry.Run("lump_sum", m, values)
+		if err != nil {
+			fmt.Printf("Erro Lump Sum %s: %v\n", symbol, err)
+			data.Notices = append(data.Notices, fmt.Sprintf("Lump Sum %s: %v", getAssetName(symbol), err))
+			continue
+		}
+		lsRes.StrategyName = fmt.Sprintf("Lump Sum %s", getAssetName(symbol))
+		if a, ok := assetCatalog.Lookup(symbol); ok {
+			lsRes = applyCredit(lsRes, a.Issuer, a.FGC, series.Currency)
+		}
 		results = append(results, lsRes)
+
+		if overlayValues != nil {
+			// Mesma posição do Lump Sum com opções mensais, para comparar a renda das opções
+			values := map[string]string{"amount": formatFloat(theoreticalTotalInvested)}
+			for k, v := range overlayValues {
+				values[k] = v
+			}
+			ovRes, err := calculator.DefaultRegistry.Run("options_overlay", m, values)
+			if err != nil {
+				data.Notices = append(data.Notices, fmt.Sprintf("Opções sobre %s: %v", getAssetName(symbol), err))
+				continue
+			}
+			addStrategyNotices(&data, "Opções sobre "+getAssetName(symbol), ovRes.Notices)
+			ovRes.StrategyName = fmt.Sprintf("%s + %s", getAssetName(symbol), ovRes.StrategyName)
+			results = append(results, ovRes)
+		}
+	}
+	if overlayValues != nil && len(lsAssets) == 0 {
+		data.Notices = append(data.Notices, "As opções sobre a posição são montadas sobre os ativos de Lump Sum: selecione ao menos um para simulá-las.")
 	}
 
 	// Processar COE Strategies
+	coeCreditNotice := false
 	if coeEnabled {
 		for _, coe := range coes {
 			// Mapear nome do ativo do COE para ticker real
-			ticker := coe.Asset
-			if coe.Asset == "BIG_TECHS" { ticker = "NASD11.SA" }
-			if coe.Asset == "IPCA" { ticker = "IMAB11.SA" }
-			if coe.Asset == "DOLAR" { ticker = "USDBRL=X" } 
-			if coe.Asset == "SP500" { ticker = "IVVB11.SA" }
+			ticker := coeCatalog.Ticker(coe.Asset)
 	
 			// Se não temos TotalInvested, usamos InitialAmount 
 			invested := theoreticalTotalInvested
 			if invested == 0 { invested = initialAmount }
 			if invested == 0 { invested = 1000 }
 	
-			histData, err := client.GetHistoricalData(ticker, startDate, endDate, true)
+			// COE é avaliado pela variação do ativo objeto na sua moeda original
+			series, err := client.GetSeries(ctx, ticker, startDate, endDate)
 			if err == nil {
-				part, _ := strconv.ParseFloat(coe.Participation, 64)
-				capLim, _ := strconv.ParseFloat(coe.Cap, 64)
-				
-				part = part / 100.0
-				capLim = capLim / 100.0
-				
-				coeRes := calculator.CalculateCOE(histData, invested, coe.Protected, part, capLim)
+				addSeriesNotices(&data, series)
+				m := market(ticker, series)
+				values := coe.params(invested, coeRateStr)
+				coeRes, err := calculator.DefaultRegistry.Run("coe", m, values)
+				if err != nil {
+					data.Notices = append(data.Notices, fmt.Sprintf("COE %s: %v", getAssetName(ticker), err))
+					continue
+				}
+				addStrategyNotices(&data, "COE "+getAssetName(ticker), coeRes.Notices)
 				coeRes.StrategyName = fmt.Sprintf("COE %s (%s)", getAssetName(ticker), coeRes.StrategyName)
-				results = append(results, coeRes)
+				if coe.Issuer != "" {
+					coeRes.StrategyName = fmt.Sprintf("%s - %s", coeRes.StrategyName, coe.Issuer)
+					// COE não tem FGC: a proteção do capital depende da solvência do emissor
+					coeRes = applyCredit(coeRes, coe.Issuer, false, "")
+					if !coeCreditNotice {
+						data.Notices = append(data.Notices, "COEs não têm cobertura do FGC: a proteção do capital vale apenas se o emissor não quebrar.")
+						coeCreditNotice = true
+					}
+				}
+				if coeRateStr != "" {
+					// A estratégia coe já traz a decomposição pelo valor justo; a replicante vem ao lado
+					replica, err := calculator.DefaultRegistry.Run("coe_replica", m, values)
+					if err != nil {
+						data.Notices = append(data.Notices, fmt.Sprintf("COE %s: %v", getAssetName(ticker), err))
+						results = append(results, coeRes)
+						continue
+					}
+					addStrategyNotices(&data, "COE "+getAssetName(ticker), replica.Notices)
+					replica.StrategyName = fmt.Sprintf("%s - COE %s", replica.StrategyName, getAssetName(ticker))
+					results = append(results, coeRes, replica)
+				} else {
+					results = append(results, coeRes)
+				}
 			} else {
 				fmt.Printf("Erro dados COE %s: %v\n", ticker, err)
 			}
 		}
 	}
 
+	// Estratégias do registro, com os parâmetros já validados
+	for _, run := range strategyRuns {
+		series, err := client.GetHistoricalDataIn(ctx, run.Asset, startDate, endDate, currency)
+		if err != nil {
+			fmt.Printf("Erro dados %s: %v\n", run.Asset, err)
+			continue
+		}
+		addSeriesNotices(&data, series)
+		values, _ := strategyParamValues(run.Params)
+		res, err := calculator.DefaultRegistry.Run(run.Strategy, market(run.Asset, series), values)
+		if err != nil {
+			data.Notices = append(data.Notices, fmt.Sprintf("%s %s: %v", run.Strategy, run.Asset, err))
+			continue
+		}
+		addStrategyNotices(&data, run.Strategy+" "+run.Asset, res.Notices)
+		res.StrategyName = fmt.Sprintf("%s - %s", getAssetName(run.Asset), res.StrategyName)
+		results = append(results, res)
+	}
+
 	// Ordenar
 	sort.Slice(results, func(i, j int) bool {
 		return results[i].ReturnPercent > results[j].ReturnPercent
@@ -377,27 +1129,188 @@ func handleSimulate(w http.ResponseWriter, r *http.Request) {
 		data.BestStrategy = results[0].StrategyName
 	}
 
-	renderTemplate(w, data)
+	return data
 }
 
-func renderError(w http.ResponseWriter, msg string) {
-	data := PageData{
-		Error:  msg,
-		Assets: SupportedAssets,
+// readUpload lê a série enviada no formulário (campo upload_file) e a registra como "UPLOAD:<nome>".
+// Devolve provider nil quando nenhum arquivo foi enviado.
+func readUpload(r *http.Request, rules finance.QualityRules) (finance.StaticProvider, string, error) {
+	file, header, err := r.FormFile("upload_file")
+	if err == http.ErrMissingFile || err == http.ErrNotMultipart {
+		return nil, "", nil
 	}
-	renderTemplate(w, data)
+	if err != nil {
+		return nil, "", err
+	}
+	defer file.Close()
+
+	format := finance.CSVFormat{
+		Delimiter:    r.FormValue("upload_delimiter"),
+		DateFormat:   r.FormValue("upload_date_format"),
+		DecimalComma: r.FormValue("upload_decimal_comma") == "on",
+		Currency:     r.FormValue("upload_currency"),
+	}
+
+	var quotes []finance.Quote
+	if strings.HasSuffix(strings.ToLower(header.Filename), ".json") {
+		quotes, err = finance.ParseJSONQuotes(file, format)
+	} else {
+		quotes, err = finance.ParseCSV(file, format)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	quotes, quality := finance.Clean(quotes, rules)
+	if len(quotes) == 0 {
+		return nil, "", fmt.Errorf("nenhuma cotação válida")
+	}
+
+	name := r.FormValue("upload_name")
+	if name == "" {
+		name = strings.TrimSuffix(header.Filename, filepath.Ext(header.Filename))
+	}
+	currency := format.Currency
+	if currency == "" {
+		currency = "BRL"
+	}
+
+	series := finance.Series{Currency: currency, Quotes: quotes, Quality: quality}
+	return finance.StaticProvider{name: series}, "UPLOAD:" + name, nil
 }
 
-func getAssetName(symbol string) string {
-	for _, a := range SupportedAssets {
-		if a.Symbol == symbol {
-			// Retornar nome curto ou o proprio nome
-			return a.Name
+// addSeriesNotices registra avisos sobre dados em cache, a qualidade dos dados e pontos preenchidos ou descartados na conversão de moeda
+func addSeriesNotices(data *PageData, series finance.Series) {
+	if series.Stale {
+		asOf := series.AsOf.Format("2006-01-02")
+		if data.DataAsOf == nil {
+			data.DataAsOf = make(map[string]string)
+		}
+		data.DataAsOf[series.Symbol] = asOf
+		data.Notices = append(data.Notices, fmt.Sprintf("%s: provedor indisponível, dados de %s (cache local)", getAssetName(series.Symbol), asOf))
+	}
+	if q := series.Quality; q != nil && q.HasIssues() {
+		data.Notices = append(data.Notices, fmt.Sprintf("%s: dados %s", getAssetName(series.Symbol), q))
+	}
+	if a := series.Alignment; a != nil && (a.Filled > 0 || a.Dropped > 0) {
+		data.Notices = append(data.Notices, fmt.Sprintf("%s: câmbio %s", getAssetName(series.Symbol), a))
+	}
+}
+
+// addStrategyNotices repassa os avisos de uma estratégia do registro, sem repetir os já dados
+// (ex: o COE e a sua carteira replicante avisam da mesma falta de histórico)
+func addStrategyNotices(data *PageData, label string, notices []string) {
+	for _, notice := range notices {
+		msg := fmt.Sprintf("%s: %s", label, notice)
+		seen := false
+		for _, n := range data.Notices {
+			if n == msg {
+				seen = true
+				break
+			}
 		}
+		if !seen {
+			data.Notices = append(data.Notices, msg)
+		}
+	}
+}
+
+// optionalMonths interpreta um intervalo em meses opcional ("" = padrão do calculador)
+func optionalMonths(v string) (int, error) {
+	if v == "" {
+		return 0, nil
+	}
+	months, err := strconv.Atoi(v)
+	if err != nil || months < 0 {
+		return 0, fmt.Errorf("intervalo inválido: %s", v)
+	}
+	return months, nil
+}
+
+// formAt devolve o i-ésimo valor de um campo repetido do formulário, ou "" se não houver
+func formAt(values []string, i int) string {
+	if i < len(values) {
+		return values[i]
+	}
+	return ""
+}
+
+// choiceOr devolve v, ou def quando o campo do formulário veio vazio
+func choiceOr(v, def string) string {
+	if v == "" {
+		return def
 	}
-	return symbol
+	return v
 }
 
+// formatFloat escreve um número como parâmetro de estratégia, sem perder precisão
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// strategyParamValues interpreta os parâmetros de uma estratégia no formato de query string
+func strategyParamValues(raw string) (map[string]string, error) {
+	query, err := url.ParseQuery(raw)
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]string, len(query))
+	for k, v := range query {
+		values[k] = v[0]
+	}
+	return values, nil
+}
+
+// strategiesJSON serializa o esquema das estratégias do registro para o JavaScript do formulário
+func strategiesJSON() template.JS {
+	b, err := json.Marshal(calculator.DefaultRegistry.Describe())
+	if err != nil {
+		return "[]"
+	}
+	return template.JS(b)
+}
+
+// coeTemplatesJSON serializa os modelos de COE para o JavaScript do formulário
+func coeTemplatesJSON() template.JS {
+	b, _ := json.Marshal(coeCatalog.Templates())
+	return template.JS(b)
+}
+
+// errorPage monta a página com uma mensagem de erro de validação
+func errorPage(msg string) PageData {
+	return PageData{
+		Error:      msg,
+		Assets:     assetCatalog.Assets(),
+		Currencies: finance.SupportedCurrencies,
+		COEUnderlyings:   coeCatalog.Underlyings(),
+		Issuers:          issuerCatalog.Issuers(),
+		COETemplates:     coeCatalog.Templates(),
+		COETemplatesJSON: coeTemplatesJSON(),
+		StrategiesJSON:   strategiesJSON(),
+		StrategyRunsJSON: "[]",
+		CustomTickersJSON: "[]",
+		COEsJSON:         "[]",
+	}
+}
+
+func getAssetName(symbol string) string {
+	return assetCatalog.Name(symbol)
+}
+
+// tradeCostsFor devolve os custos de negociação padrão do ativo no catálogo, se habilitados no formulário
+func tradeCostsFor(symbol string, enabled bool) calculator.TradeCosts {
+	if a, ok := assetCatalog.Lookup(symbol); ok && enabled && a.CostModel != nil {
+		return *a.CostModel
+	}
+	return calculator.TradeCosts{}
+}
+
+// issuerCountry devolve o país do emissor cadastrado no catálogo ou, na falta dele, o deduzido da moeda do ativo
+func issuerCountry(symbol string, series finance.Series) string {
+	if a, ok := assetCatalog.Lookup(symbol); ok && a.Country != "" {
+		return a.Country
+	}
+	return calculator.CountryForCurrency(series.OriginalCurrency())
+}
 
 func renderTemplate(w http.ResponseWriter, data PageData) {
 	// Como main.go está na raiz, templates/index.html funcionará
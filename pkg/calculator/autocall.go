@@ -0,0 +1,94 @@
+package calculator
+
+import (
+	"dca-platform/pkg/finance"
+	"math"
+	"time"
+)
+
+// COEAutocall descreve o resgate antecipado automático: em cada data de observação, se o ativo
+// estiver no gatilho ou acima, o COE é resgatado com o cupom acumulado dos períodos decorridos.
+type COEAutocall struct {
+	Trigger           float64 `json:"trigger"`                      // Fração do preço inicial (1.0 = 100%)
+	Coupon            float64 `json:"coupon"`                       // Cupom por período de observação (0.04 = 4%)
+	ObservationMonths int     `json:"observation_months,omitempty"` // Intervalo entre observações (padrão 6)
+}
+
+// COECoupon descreve cupons periódicos: fixos (sem barreira) ou condicionais ao ativo
+// estar na barreira ou acima na data de observação. Com memória, cupons não pagos
+// são pagos de uma vez na próxima observação em que a condição for atendida.
+type COECoupon struct {
+	Rate              float64 `json:"rate"`                         // Cupom por período (0.02 = 2%)
+	ObservationMonths int     `json:"observation_months,omitempty"` // Intervalo entre cupons (padrão 3)
+	Barrier           float64 `json:"barrier,omitempty"`            // Fração do preço inicial; 0 = cupom fixo
+	Memory            bool    `json:"memory,omitempty"`
+}
+
+// observationIndexes devolve os índices do caminho nas datas de observação (primeiro pregão
+// a partir de cada data agendada a cada months meses desde o início). Com includeLast,
+// o último ponto (vencimento) também é observado.
+func observationIndexes(path []finance.Quote, months int, includeLast bool) []int {
+	var idx []int
+	next := path[0].Date.AddDate(0, months, 0)
+	for i := 1; i < len(path); i++ {
+		if !path[i].Date.Before(next) {
+			idx = append(idx, i)
+			for !path[i].Date.Before(next) {
+				next = next.AddDate(0, months, 0)
+			}
+		}
+	}
+	if includeLast && len(path) > 1 && (len(idx) == 0 || idx[len(idx)-1] != len(path)-1) {
+		idx = append(idx, len(path)-1)
+	}
+	return idx
+}
+
+// callIndex devolve o índice do resgate antecipado e quantos períodos se passaram até ele (0 se não houve)
+func (a *COEAutocall) callIndex(path []finance.Quote) (int, int) {
+	months := a.ObservationMonths
+	if months <= 0 {
+		months = 6
+	}
+	trigger := path[0].Close * a.Trigger
+	for k, i := range observationIndexes(path, months, false) {
+		if path[i].Close >= trigger {
+			return i, k + 1
+		}
+	}
+	return 0, 0
+}
+
+// paid devolve o total de cupons (fração do valor aplicado) observados até o índice end do caminho
+func (c *COECoupon) paid(path []finance.Quote, end int) float64 {
+	months := c.ObservationMonths
+	if months <= 0 {
+		months = 3
+	}
+	barrier := path[0].Close * c.Barrier
+
+	var total float64
+	missed := 0
+	for _, i := range observationIndexes(path[:end+1], months, false) {
+		if c.Barrier == 0 || path[i].Close >= barrier {
+			periods := 1
+			if c.Memory {
+				periods += missed
+			}
+			total += c.Rate * float64(periods)
+			missed = 0
+		} else {
+			missed++
+		}
+	}
+	return total
+}
+
+// annualized converte um retorno total (fração) em taxa ao ano
+func annualized(ret float64, from, to time.Time) float64 {
+	years := yearsBetween(from, to)
+	if years <= 0 || ret <= -1 {
+		return 0
+	}
+	return math.Pow(1+ret, 1/years) - 1
+}
@@ -0,0 +1,269 @@
+package calculator
+
+import (
+	"fmt"
+	"strings"
+)
+
+// funcStrategy adapta uma função de cálculo à interface Strategy
+type funcStrategy struct {
+	name        string
+	description string
+	params      []Param
+	check       func(p Params) error // Regras além do esquema (opcional)
+	run         func(m Market, p Params) (StrategyResult, error)
+}
+
+func (s funcStrategy) Name() string        { return s.name }
+func (s funcStrategy) Description() string { return s.description }
+func (s funcStrategy) Params() []Param     { return s.params }
+func (s funcStrategy) Run(m Market, p Params) (StrategyResult, error) {
+	return s.run(m, p)
+}
+
+func (s funcStrategy) Check(p Params) error {
+	if s.check == nil {
+		return nil
+	}
+	return s.check(p)
+}
+
+// amountParam é o valor aplicado de uma vez no início
+var amountParam = Param{Name: "amount", Label: "Valor Aplicado", Type: ParamNumber, Default: "1000", Min: Bound(0.01)}
+
+// positionParams são as opções das estratégias de compra (ver DCAOptions)
+var positionParams = []Param{
+	{Name: "dividend_mode", Label: "Proventos", Type: ParamChoice, Default: "ignore", Choices: []string{"ignore", string(DividendsAdjusted), string(DividendsReinvest), string(DividendsCash)}},
+	{Name: "withholding", Label: "Reter IR sobre Proventos", Type: ParamBool, Default: "false", Help: "JCP 15% (emissor BR), EUA 30%"},
+	{Name: "jcp_share", Label: "JCP nos Proventos BR (%)", Type: ParamNumber, Default: "0", Min: Bound(0), Max: Bound(100)},
+	{Name: "execution", Label: "Preço de Execução", Type: ParamChoice, Default: "close", Choices: []string{"close", string(ExecOpen), string(ExecTypical), string(ExecRandom)}},
+	{Name: "brokerage", Label: "Corretagem por Ordem", Type: ParamNumber, Default: "0", Min: Bound(0)},
+	{Name: "fee", Label: "Emolumentos/Spread (%)", Type: ParamNumber, Default: "0", Min: Bound(0), Max: Bound(100)},
+}
+
+// positionOptions monta as DCAOptions a partir dos positionParams
+func positionOptions(m Market, p Params) DCAOptions {
+	opts := DCAOptions{
+		Dividends: m.Dividends,
+		Costs:     TradeCosts{Brokerage: p.Float("brokerage"), Fee: p.Float("fee") / 100},
+	}
+	if mode := p.String("dividend_mode"); mode != "ignore" {
+		opts.DividendMode = DividendMode(mode)
+	}
+	if exec := p.String("execution"); exec != "close" {
+		opts.Execution = ExecutionPrice(exec)
+	}
+	if p.Bool("withholding") {
+		opts.Withholding = WithholdingFor(m.Country, p.Float("jcp_share")/100)
+	}
+	return opts
+}
+
+// coeParams descrevem um COE; níveis, retornos e cupons em %
+var coeParams = []Param{
+	amountParam,
+	{Name: "protected", Label: "Capital Protegido", Type: ParamBool, Default: "true"},
+	{Name: "participation", Label: "Participação (%)", Type: ParamNumber, Default: "100", Min: Bound(0)},
+	{Name: "cap", Label: "Teto (%)", Type: ParamNumber, Default: "0", Min: Bound(0), Help: "0 = sem teto"},
+	{Name: "payoff", Label: "Payoff", Type: ParamChoice, Default: "point", Choices: []string{"point", string(PayoffAsian), string(PayoffDigital)}},
+	{Name: "strike", Label: "Strike Digital (%)", Type: ParamNumber, Default: "100", Min: Bound(1)},
+	{Name: "digital_return", Label: "Retorno Digital (%)", Type: ParamNumber, Default: "10", Min: Bound(0)},
+	{Name: "term_months", Label: "Prazo (meses)", Type: ParamInteger, Default: "0", Min: Bound(0), Help: "0 = janela inteira"},
+	{Name: "rollover", Label: "No Vencimento", Type: ParamChoice, Default: "renew", Choices: []string{"renew", string(RollFallback), string(RollCash)}},
+	{Name: "fallback", Label: "Ativo Alternativo", Type: ParamString, Help: "ativo do resgate no modo fallback, na moeda do ativo objeto"},
+	{Name: "barrier_kind", Label: "Barreira", Type: ParamChoice, Default: "none", Choices: []string{"none", string(KnockOutUp), string(KnockInDown)}},
+	{Name: "barrier_level", Label: "Nível da Barreira (%)", Type: ParamNumber, Default: "0", Min: Bound(0)},
+	{Name: "barrier_rebate", Label: "Rebate do Knock-out (%)", Type: ParamNumber, Default: "0", Min: Bound(0)},
+	{Name: "barrier_observation", Label: "Observação da Barreira", Type: ParamChoice, Default: "continuous", Choices: []string{"continuous", string(ObserveDiscrete)}},
+	{Name: "barrier_months", Label: "Intervalo da Observação Discreta (meses)", Type: ParamInteger, Default: "0", Min: Bound(0), Help: "0 = mensal"},
+	{Name: "autocall_trigger", Label: "Gatilho do Autocall (%)", Type: ParamNumber, Default: "0", Min: Bound(0), Help: "0 = sem autocall"},
+	{Name: "autocall_coupon", Label: "Cupom do Autocall (%)", Type: ParamNumber, Default: "0", Min: Bound(0)},
+	{Name: "autocall_months", Label: "Intervalo do Autocall (meses)", Type: ParamInteger, Default: "0", Min: Bound(0), Help: "0 = semestral"},
+	{Name: "coupon_rate", Label: "Cupom Periódico (%)", Type: ParamNumber, Default: "0", Min: Bound(0), Help: "0 = sem cupom"},
+	{Name: "coupon_barrier", Label: "Barreira do Cupom (%)", Type: ParamNumber, Default: "0", Min: Bound(0), Help: "0 = cupom fixo"},
+	{Name: "coupon_months", Label: "Intervalo dos Cupons (meses)", Type: ParamInteger, Default: "0", Min: Bound(0), Help: "0 = trimestral"},
+	{Name: "coupon_memory", Label: "Cupons com Memória", Type: ParamBool, Default: "false"},
+	{Name: "rate", Label: "Taxa Pré/CDI (% a.a.)", Type: ParamNumber, Min: Bound(-99), Help: "valor justo do COE; vazio = sem decomposição"},
+}
+
+// checkCOE recusa combinações que o esquema sozinho não cobre
+func checkCOE(p Params) error {
+	if p.String("rollover") == string(RollFallback) && strings.TrimSpace(p.String("fallback")) == "" {
+		return fmt.Errorf("fallback: informe o ativo alternativo")
+	}
+	if p.String("barrier_kind") != "none" && p.Float("barrier_level") <= 0 {
+		return fmt.Errorf("barrier_level: informe o nível da barreira")
+	}
+	return nil
+}
+
+// coeOptions monta as COEOptions a partir dos coeParams. Sem dados do ativo alternativo,
+// os resgates ficam em caixa e o aviso volta junto.
+func coeOptions(m Market, p Params) (COEOptions, []string) {
+	opts := COEOptions{
+		Protected:     p.Bool("protected"),
+		Participation: p.Float("participation") / 100,
+		Cap:           p.Float("cap") / 100,
+		TermMonths:    p.Int("term_months"),
+	}
+	if _, ok := p["rate"]; ok {
+		// Só o valor justo usa o histórico anterior à janela
+		opts.History = m.history()
+	}
+	switch payoff := COEPayoff(p.String("payoff")); payoff {
+	case PayoffAsian:
+		opts.Payoff = payoff
+	case PayoffDigital:
+		opts.Payoff = payoff
+		opts.Strike = p.Float("strike") / 100
+		opts.DigitalReturn = p.Float("digital_return") / 100
+	}
+	if kind := p.String("barrier_kind"); kind != "none" {
+		opts.Barrier = &COEBarrier{
+			Kind:              BarrierKind(kind),
+			Level:             p.Float("barrier_level") / 100,
+			Rebate:            p.Float("barrier_rebate") / 100,
+			ObservationMonths: p.Int("barrier_months"),
+		}
+		if p.String("barrier_observation") == string(ObserveDiscrete) {
+			opts.Barrier.Observation = ObserveDiscrete
+		}
+	}
+	if trigger := p.Float("autocall_trigger"); trigger > 0 {
+		opts.Autocall = &COEAutocall{
+			Trigger:           trigger / 100,
+			Coupon:            p.Float("autocall_coupon") / 100,
+			ObservationMonths: p.Int("autocall_months"),
+		}
+	}
+	if rate := p.Float("coupon_rate"); rate > 0 {
+		opts.Coupon = &COECoupon{
+			Rate:              rate / 100,
+			Barrier:           p.Float("coupon_barrier") / 100,
+			ObservationMonths: p.Int("coupon_months"),
+			Memory:            p.Bool("coupon_memory"),
+		}
+	}
+
+	var notices []string
+	switch COERollover(p.String("rollover")) {
+	case RollCash:
+		opts.Rollover = RollCash
+	case RollFallback:
+		opts.Rollover = RollFallback
+		fallback := strings.TrimSpace(p.String("fallback"))
+		// Ativo alternativo na moeda do ativo objeto, como o próprio COE
+		quotes, err := m.fetch(fallback, m.Currency)
+		if err != nil {
+			notices = append(notices, fmt.Sprintf("sem dados de %s, resgates mantidos em caixa", fallback))
+		}
+		opts.Fallback = quotes
+	}
+	return opts, notices
+}
+
+func init() {
+	Register(funcStrategy{
+		name:        "dca",
+		description: "DCA (aportes periódicos)",
+		params: append([]Param{
+			{Name: "initial_amount", Label: "Aporte Inicial", Type: ParamNumber, Default: "0", Min: Bound(0)},
+			{Name: "amount", Label: "Aporte Periódico", Type: ParamNumber, Default: "100", Min: Bound(0)},
+			{Name: "frequency", Label: "Frequência", Type: ParamChoice, Default: string(Monthly), Choices: []string{string(Daily), string(Weekly), string(Monthly)}},
+			{Name: "contribution_currency", Label: "Moeda dos Aportes", Type: ParamString, Help: "ex: BRL; vazio = moeda do ativo"},
+			{Name: "iof", Label: "IOF da Remessa (%)", Type: ParamNumber, Default: "0", Min: Bound(0), Max: Bound(100)},
+			{Name: "fx_spread", Label: "Spread Cambial (%)", Type: ParamNumber, Default: "0", Min: Bound(0), Max: Bound(100)},
+		}, positionParams...),
+		run: func(m Market, p Params) (StrategyResult, error) {
+			opts := positionOptions(m, p)
+			initial, amount, freq := p.Float("initial_amount"), p.Float("amount"), Frequency(p.String("frequency"))
+			if local := strings.ToUpper(strings.TrimSpace(p.String("contribution_currency"))); local != "" && local != m.Currency {
+				// Aportes fixos na moeda do investidor, convertidos pelo câmbio de cada compra
+				fx, err := m.rates(m.Currency, local)
+				if err != nil {
+					return StrategyResult{}, err
+				}
+				costs := FXCosts{IOF: p.Float("iof") / 100, Spread: p.Float("fx_spread") / 100}
+				return CalculateDCALocalCurrency(m.Quotes, fx, local, initial, amount, freq, costs, opts), nil
+			}
+			return CalculateDCAWithOptions(m.Quotes, initial, amount, freq, opts), nil
+		},
+	})
+
+	Register(funcStrategy{
+		name:        "lump_sum",
+		description: "Lump Sum (compra única)",
+		params:      append([]Param{amountParam}, positionParams...),
+		run: func(m Market, p Params) (StrategyResult, error) {
+			return CalculateLumpSumWithOptions(m.Quotes, p.Float("amount"), "Lump Sum", positionOptions(m, p)), nil
+		},
+	})
+
+	Register(funcStrategy{
+		name:        "coe",
+		description: "COE",
+		params:      coeParams,
+		check:       checkCOE,
+		run: func(m Market, p Params) (StrategyResult, error) {
+			opts, notices := coeOptions(m, p)
+			res := CalculateCOEWithOptions(m.Quotes, p.Float("amount"), opts)
+			if _, ok := p["rate"]; ok && len(m.Quotes) > 1 {
+				// Decomposição em prefixado + opções pelo valor justo
+				valuation := ValueCOE(m.Quotes, opts, p.Float("rate")/100)
+				res.COEValuation = &valuation
+				if notice := valuation.volatilityNotice(m.Quotes[0].Date); notice != "" {
+					notices = append(notices, notice)
+				}
+			}
+			res.Notices = notices
+			return res, nil
+		},
+	})
+
+	// A carteira que replica o COE pelo valor justo exige a taxa pré/CDI
+	replicaParams := append([]Param(nil), coeParams...)
+	for i := range replicaParams {
+		if replicaParams[i].Name == "rate" {
+			replicaParams[i].Required = true
+		}
+	}
+	Register(funcStrategy{
+		name:        "coe_replica",
+		description: "Carteira replicante de COE (prefixado + opções)",
+		params:      replicaParams,
+		check:       checkCOE,
+		run: func(m Market, p Params) (StrategyResult, error) {
+			opts, notices := coeOptions(m, p)
+			res := CalculateCOEReplication(m.Quotes, p.Float("amount"), opts, p.Float("rate")/100)
+			res.Notices = append(notices, res.Notices...)
+			return res, nil
+		},
+	})
+
+	Register(funcStrategy{
+		name:        "options_overlay",
+		description: "Compra única com opções mensais",
+		params: []Param{
+			amountParam,
+			{Name: "kind", Label: "Estratégia", Type: ParamChoice, Default: string(CoveredCall), Choices: []string{string(CoveredCall), string(ProtectivePut)}},
+			{Name: "moneyness", Label: "Strike (% do preço)", Type: ParamNumber, Default: "105", Min: Bound(1)},
+			{Name: "premiums", Label: "Prêmios", Type: ParamChoice, Default: "reinvest", Choices: []string{"reinvest", string(PremiumCash)}},
+			{Name: "rate", Label: "Taxa Livre de Risco (% a.a.)", Type: ParamNumber, Default: "10", Min: Bound(-99)},
+		},
+		run: func(m Market, p Params) (StrategyResult, error) {
+			opts := OverlayOptions{
+				Kind:      OverlayKind(p.String("kind")),
+				Moneyness: p.Float("moneyness") / 100,
+				Rate:      p.Float("rate") / 100,
+				History:   m.history(),
+			}
+			if p.String("premiums") == string(PremiumCash) {
+				opts.Premiums = PremiumCash
+			}
+			return CalculateOverlay(m.Quotes, p.Float("amount"), opts), nil
+		},
+	})
+
+	// Script próprio informado a cada execução (ver script.go); arquivos .script são registrados por RegisterScripts
+	Register(&ScriptStrategy{name: "script", description: "Script próprio"})
+}
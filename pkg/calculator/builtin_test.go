@@ -0,0 +1,133 @@
+package calculator
+
+import (
+	"dca-platform/pkg/finance"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuiltinCOERollover(t *testing.T) {
+	quotes := monthlyQuotes(100, 110, 120, 130, 140, 150, 160)
+	fallback := []finance.Quote{
+		{Date: time.Date(2020, time.April, 1, 0, 0, 0, 0, time.UTC), Close: 50},
+		{Date: time.Date(2020, time.July, 1, 0, 0, 0, 0, time.UTC), Close: 100},
+	}
+	tests := []struct {
+		name        string
+		fetch       func(symbol, currency string) ([]finance.Quote, error)
+		wantValue   float64
+		wantNotices int
+	}{
+		{"ativo alternativo", func(symbol, currency string) ([]finance.Quote, error) {
+			if symbol != "ALT" || currency != "USD" {
+				return nil, fmt.Errorf("busca inesperada %s em %s", symbol, currency)
+			}
+			return fallback, nil
+		}, 2600, 0},
+		{"sem dados do alternativo", func(symbol, currency string) ([]finance.Quote, error) {
+			return nil, fmt.Errorf("fora do ar")
+		}, 1300, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := Market{Currency: "USD", Quotes: quotes, Fetch: tt.fetch}
+			res, err := DefaultRegistry.Run("coe", m, map[string]string{
+				"amount": "1000", "protected": "false", "term_months": "3", "rollover": "fallback", "fallback": "ALT",
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if math.Abs(res.FinalValue-tt.wantValue) > 1e-6 {
+				t.Errorf("valor final = %.4f, esperado %.4f", res.FinalValue, tt.wantValue)
+			}
+			if len(res.Notices) != tt.wantNotices {
+				t.Errorf("avisos = %q, esperado %d", res.Notices, tt.wantNotices)
+			}
+		})
+	}
+}
+
+func TestBuiltinCOECheck(t *testing.T) {
+	tests := []struct {
+		name    string
+		values  map[string]string
+		wantErr string // "" = válido
+	}{
+		{"padrão", map[string]string{}, ""},
+		{"alternativo sem ativo", map[string]string{"rollover": "fallback"}, "fallback"},
+		{"barreira sem nível", map[string]string{"barrier_kind": "knock_in"}, "barrier_level"},
+		{"barreira com nível", map[string]string{"barrier_kind": "knock_in", "barrier_level": "70"}, ""},
+		{"payoff desconhecido", map[string]string{"payoff": "binary"}, "payoff"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := DefaultRegistry.Validate("coe", tt.values)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("erro inesperado: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("erro = %v, esperado sobre %s", err, tt.wantErr)
+			}
+		})
+	}
+	// A carteira replicante exige a taxa pré/CDI
+	if _, _, err := DefaultRegistry.Validate("coe_replica", map[string]string{}); err == nil {
+		t.Error("coe_replica aceito sem taxa")
+	}
+}
+
+func TestBuiltinDCALocalCurrency(t *testing.T) {
+	quotes := monthlyQuotes(10, 20, 10)
+	fx := monthlyQuotes(0.2, 0.25, 0.2) // USD por BRL
+	values := map[string]string{"amount": "500", "contribution_currency": "brl", "iof": "1.1"}
+
+	m := Market{Currency: "USD", Quotes: quotes, FX: func(from, to string) ([]finance.Quote, error) {
+		if from != "USD" || to != "BRL" {
+			return nil, fmt.Errorf("câmbio inesperado %s/%s", from, to)
+		}
+		return fx, nil
+	}}
+	res, err := DefaultRegistry.Run("dca", m, values)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := CalculateDCALocalCurrency(quotes, fx, "BRL", 0, 500, Monthly, FXCosts{IOF: 0.011}, DCAOptions{})
+	if res.LocalCurrency != "BRL" || math.Abs(res.FinalValue-want.FinalValue) > 1e-9 || math.Abs(res.LocalInvested-want.LocalInvested) > 1e-9 {
+		t.Errorf("resultado = %+v, esperado %+v", res, want)
+	}
+
+	// Sem câmbio a simulação falha em vez de tratar os aportes como se fossem na moeda do ativo
+	if _, err := DefaultRegistry.Run("dca", Market{Currency: "USD", Quotes: quotes}, values); err == nil {
+		t.Error("esperado erro sem acesso ao câmbio")
+	}
+}
+
+func TestBuiltinLumpSumWithholdingByCountry(t *testing.T) {
+	quotes := monthlyQuotes(100, 100, 100)
+	dividends := []finance.Dividend{{Date: time.Date(2020, time.February, 15, 0, 0, 0, 0, time.UTC), Amount: 1}}
+	tests := []struct {
+		country  string
+		wantCash float64
+	}{
+		{"US", 7},
+		{"BR", 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.country, func(t *testing.T) {
+			m := Market{Currency: "USD", Quotes: quotes, Dividends: dividends, Country: tt.country}
+			res, err := DefaultRegistry.Run("lump_sum", m, map[string]string{"amount": "1000", "dividend_mode": "cash", "withholding": "true"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if math.Abs(res.CashBalance-tt.wantCash) > 1e-9 {
+				t.Errorf("caixa = %.4f, esperado %.4f", res.CashBalance, tt.wantCash)
+			}
+		})
+	}
+}
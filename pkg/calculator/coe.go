@@ -3,8 +3,100 @@ package calculator
 import (
 	"dca-platform/pkg/finance"
 	"fmt"
+	"time"
 )
 
+// COERollover define o destino do dinheiro quando um COE vence antes do fim da simulação
+type COERollover string
+
+const (
+	RollNewNote  COERollover = ""         // Novo COE com as mesmas condições a cada vencimento
+	RollFallback COERollover = "fallback" // Resgate aplicado no ativo alternativo até o fim da janela
+	RollCash     COERollover = "cash"     // Resgate mantido em caixa, sem rendimento
+)
+
+// BarrierKind define o efeito da barreira de um COE
+type BarrierKind string
+
+const (
+	KnockOutUp  BarrierKind = "knock_out" // Ativo acima do nível: o retorno vira o rebate fixo
+	KnockInDown BarrierKind = "knock_in"  // Ativo abaixo do nível: perde a proteção e acompanha a queda 1:1
+)
+
+// BarrierObservation define quando a barreira é verificada
+type BarrierObservation string
+
+const (
+	ObserveContinuous BarrierObservation = ""         // Todo pregão (máxima/mínima do dia, se disponíveis)
+	ObserveDiscrete   BarrierObservation = "discrete" // Só nas datas de observação (a cada ObservationMonths) e no vencimento
+)
+
+// COEPayoff define como o retorno do ativo objeto é medido no vencimento
+type COEPayoff string
+
+const (
+	PayoffPointToPoint COEPayoff = ""        // Variação entre a emissão e o vencimento
+	PayoffAsian        COEPayoff = "asian"   // Média das observações mensais contra o preço inicial
+	PayoffDigital      COEPayoff = "digital" // Retorno fixo se o ativo terminar no strike ou acima, senão o principal
+)
+
+// COEBarrier descreve uma barreira knock-in/knock-out
+type COEBarrier struct {
+	Kind              BarrierKind        `json:"kind"`
+	Level             float64            `json:"level"`                        // Fração do preço inicial (1.30 = 130%)
+	Rebate            float64            `json:"rebate,omitempty"`             // Retorno pago no knock-out (0.05 = 5%)
+	Observation       BarrierObservation `json:"observation,omitempty"`        // Contínua (padrão) ou discreta
+	ObservationMonths int                `json:"observation_months,omitempty"` // Intervalo das observações discretas (padrão 1)
+}
+
+// COEOptions descreve as condições de um COE
+type COEOptions struct {
+	Protected     bool      // Valor no vencimento nunca menor que o aplicado
+	Participation float64   // Participação na alta (ex: 1.0 para 100%)
+	Cap           float64   // Retorno bruto máximo (ex: 0.20 para 20%). 0 = sem limite
+	Payoff        COEPayoff // Ponta a ponta (padrão), asiático ou digital
+	Strike        float64   // Digital: fração do preço inicial exigida no vencimento (0 = 100%)
+	DigitalReturn float64   // Digital: retorno pago acima do strike (ex: 0.10 para 10%)
+	Barrier       *COEBarrier
+	Autocall      *COEAutocall // Resgate antecipado automático
+	Coupon        *COECoupon   // Cupons periódicos fixos ou condicionais
+
+	TermMonths int             // Prazo de cada COE. 0 = um único COE do início ao fim da simulação
+	Rollover   COERollover     // O que fazer com o resgate de cada vencimento
+	Fallback   []finance.Quote // Ativo alternativo do modo RollFallback, na mesma moeda do ativo objeto
+
+	// History são os pregões anteriores à janela, usados só na volatilidade do valor justo
+	// dos primeiros COEs (ver ValueCOE); sem eles, a estimativa usa apenas a própria janela
+	History []finance.Quote
+}
+
+// COENote é o resultado de um COE individual dentro da simulação
+type COENote struct {
+	Start            time.Time
+	End              time.Time // Vencimento ou resgate antecipado, ou fim da simulação se ainda não venceu
+	Matured          bool      // false: avaliado pelo valor intrínseco no fim da janela, antes do vencimento
+	Invested         float64
+	Value            float64
+	UnderlyingReturn float64 // Variação do ativo objeto no período do COE (%)
+	Return           float64 // Retorno do COE (%)
+
+	BarrierHit  bool      // A barreira foi atingida durante o COE
+	BarrierDate time.Time // Primeira data em que a barreira foi atingida
+
+	Called  bool    // Resgatado antecipadamente pelo autocall
+	Coupons float64 // Cupons pagos, inclusive o do autocall (% do aplicado, já somados ao Return)
+}
+
+// noteOutcome é o resultado do payoff de um COE sobre o caminho do ativo objeto
+type noteOutcome struct {
+	ret         float64
+	barrierHit  bool
+	barrierDate time.Time
+	end         int // Índice do resgate no caminho (antes do fim se houve autocall)
+	called      bool
+	coupons     float64
+}
+
 // CalculateCOE calcula o retorno de um COE (Capital Protegido e/ou Capado)
 // initialAmount: Valor aportado
 // quotes: Histórico do ativo objeto
@@ -12,50 +104,309 @@ import (
 // participation: % de participação na alta (ex: 1.0 para 100%)
 // capLimit: % máxima de retorno bruto permitida (ex: 0.20 para 20%). Use 0 para sem limite.
 func CalculateCOE(quotes []finance.Quote, initialAmount float64, protected bool, participation float64, capLimit float64) StrategyResult {
+	return CalculateCOEWithOptions(quotes, initialAmount, COEOptions{
+		Protected:     protected,
+		Participation: participation,
+		Cap:           capLimit,
+	})
+}
+
+// CalculateCOEWithOptions simula COEs sucessivos com prazo fixo ao longo da janela.
+// A cada vencimento o resgate vai para um novo COE, para o ativo alternativo ou fica em caixa,
+// conforme opts.Rollover. O último COE, se não vencer dentro da janela, é avaliado pelo valor intrínseco.
+// Um COE resgatado pelo autocall conta como vencido na data do resgate.
+func CalculateCOEWithOptions(quotes []finance.Quote, initialAmount float64, opts COEOptions) StrategyResult {
 	if len(quotes) == 0 {
 		return StrategyResult{StrategyName: "COE (Sem dados)"}
 	}
 
-	startPrice := quotes[0].Close
-	endPrice := quotes[len(quotes)-1].Close
+	value, notes := opts.simulateNotes(quotes, initialAmount, func(start, end int, outcome noteOutcome) float64 {
+		return 1 + outcome.ret
+	})
+	return opts.result(quotes, initialAmount, value, notes)
+}
 
-	// Rentabilidade do ativo objeto
-	assetReturn := (endPrice - startPrice) / startPrice
+// simulateNotes percorre os COEs sucessivos da janela. growth devolve o fator pelo qual o valor
+// aplicado em cada COE (de quotes[start] a quotes[end]) é multiplicado até o resgate.
+func (o COEOptions) simulateNotes(quotes []finance.Quote, initialAmount float64, growth func(start, end int, outcome noteOutcome) float64) (float64, []COENote) {
+	value := initialAmount
+	var notes []COENote
+	start := 0
+	for {
+		end, matured := len(quotes)-1, true
+		if o.TermMonths > 0 {
+			end, matured = maturityIndex(quotes, start, o.TermMonths)
+		}
+
+		outcome := o.payoff(quotes[start : end+1])
+		if outcome.called {
+			end, matured = start+outcome.end, true
+		}
+		factor := growth(start, end, outcome)
+		note := COENote{
+			Start:            quotes[start].Date,
+			End:              quotes[end].Date,
+			Matured:          matured,
+			Invested:         value,
+			Value:            value * factor,
+			UnderlyingReturn: pathReturn(quotes[start:end+1]) * 100,
+			Return:           (factor - 1) * 100,
+			BarrierHit:       outcome.barrierHit,
+			BarrierDate:      outcome.barrierDate,
+			Called:           outcome.called,
+			Coupons:          outcome.coupons * 100,
+		}
+		notes = append(notes, note)
+		value = note.Value
+
+		if !matured || end == len(quotes)-1 {
+			break
+		}
+		if o.Rollover == RollFallback {
+			value *= 1 + fallbackReturn(o.Fallback, quotes[end].Date, quotes[len(quotes)-1].Date)
+			break
+		}
+		if o.Rollover == RollCash {
+			break
+		}
+		start = end
+	}
+	return value, notes
+}
+
+// result monta o StrategyResult de uma simulação de COEs
+func (o COEOptions) result(quotes []finance.Quote, initialAmount, value float64, notes []COENote) StrategyResult {
+	first, last := quotes[0].Date, quotes[len(quotes)-1].Date
+	return StrategyResult{
+		StrategyName:     o.name(),
+		TotalInvested:    initialAmount,
+		FinalValue:       value,
+		ReturnPercent:    (value - initialAmount) / initialAmount * 100,
+		TotalAccumulated: 0, // COE não acumula cotas, é um derivativo
+		COENotes:         notes,
+		AnnualizedReturn: annualized(value/initialAmount-1, first, last) * 100,
+		HoldReturn:       pathReturn(quotes) * 100,
+	}
+}
+
+// payoff devolve o retorno do COE sobre o caminho do ativo objeto entre a emissão e o vencimento.
+// Cupons pagos até o resgate são somados ao retorno, sem reinvestimento.
+func (o COEOptions) payoff(path []finance.Quote) noteOutcome {
+	out := o.structuredPayoff(path)
+	if out.end == 0 {
+		out.end = len(path) - 1
+	}
+	if o.Coupon != nil {
+		paid := o.Coupon.paid(path, out.end)
+		out.coupons += paid
+		out.ret += paid
+	}
+	return out
+}
+
+// structuredPayoff aplica autocall, barreira, participação, teto e proteção.
+// O autocall prevalece: resgatado antecipadamente, o COE paga só o cupom acumulado.
+func (o COEOptions) structuredPayoff(path []finance.Quote) noteOutcome {
+	var out noteOutcome
+	if o.Autocall != nil {
+		if i, periods := o.Autocall.callIndex(path); periods > 0 {
+			out.end, out.called = i, true
+			out.coupons = o.Autocall.Coupon * float64(periods)
+			out.ret = out.coupons
+			return out
+		}
+	}
+
+	underlying := pathReturn(path)
+	protected := o.Protected
+	if o.Payoff == PayoffAsian {
+		underlying = averageReturn(path)
+	}
+
+	if o.Barrier != nil {
+		out.barrierDate, out.barrierHit = o.Barrier.hit(path)
+		if out.barrierHit {
+			switch o.Barrier.Kind {
+			case KnockOutUp:
+				out.ret = o.Barrier.Rebate
+				return out
+			case KnockInDown:
+				// Proteção perdida: a queda é repassada integralmente
+				protected = false
+				if underlying < 0 {
+					out.ret = underlying
+					return out
+				}
+			}
+		}
+	}
+
+	if o.Payoff == PayoffDigital {
+		strike := o.Strike
+		if strike <= 0 {
+			strike = 1
+		}
+		switch {
+		case path[len(path)-1].Close >= path[0].Close*strike:
+			out.ret = o.DigitalReturn
+		case !protected && underlying < 0:
+			out.ret = underlying
+		}
+		return out
+	}
 
 	// Aplica participação
-	grossReturn := assetReturn * participation
+	grossReturn := underlying * o.Participation
 
 	// Aplica Cap (Teto) na ALTA
-	if capLimit > 0 && grossReturn > capLimit {
-		grossReturn = capLimit
+	if o.Cap > 0 && grossReturn > o.Cap {
+		grossReturn = o.Cap
 	}
 
 	// Aplica Capital Protegido na BAIXA
 	if protected && grossReturn < 0 {
 		grossReturn = 0
 	}
+	out.ret = grossReturn
+	return out
+}
 
-	finalValue := initialAmount * (1 + grossReturn)
-	netReturn := grossReturn * 100 // Em %
+// hit procura a primeira data em que o caminho atinge a barreira
+func (b *COEBarrier) hit(path []finance.Quote) (time.Time, bool) {
+	level := path[0].Close * b.Level
+	every := b.ObservationMonths
+	if every <= 0 {
+		every = 1
+	}
+	nextObservation := path[0].Date.AddDate(0, every, 0)
 
-	// Nome descritivo
+	for i, q := range path[1:] {
+		price := q.Close
+		if b.Observation == ObserveDiscrete {
+			// Observa no primeiro pregão a partir de cada data de observação e no vencimento
+			last := i == len(path)-2
+			if q.Date.Before(nextObservation) && !last {
+				continue
+			}
+			for !q.Date.Before(nextObservation) {
+				nextObservation = nextObservation.AddDate(0, every, 0)
+			}
+		} else if b.Kind == KnockOutUp && q.High > 0 {
+			price = q.High
+		} else if b.Kind == KnockInDown && q.Low > 0 {
+			price = q.Low
+		}
+
+		if (b.Kind == KnockOutUp && price >= level) || (b.Kind == KnockInDown && price <= level) {
+			return q.Date, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// name monta o nome descritivo do COE
+func (o COEOptions) name() string {
 	protStr := "Sem Proteção"
-	if protected {
+	if o.Protected {
 		protStr = "Protegido"
 	}
-	
+
 	capStr := "Sem Teto"
-	if capLimit > 0 {
-		capStr = fmt.Sprintf("Cap %.0f%%", capLimit*100)
+	if o.Cap > 0 {
+		capStr = fmt.Sprintf("Cap %.0f%%", o.Cap*100)
 	}
 
-	strategyName := fmt.Sprintf("COE (%s, Part. %.0f%%, %s)", protStr, participation*100, capStr)
+	name := fmt.Sprintf("COE (%s, Part. %.0f%%, %s", protStr, o.Participation*100, capStr)
+	switch o.Payoff {
+	case PayoffAsian:
+		name += ", asiático"
+	case PayoffDigital:
+		strike := o.Strike
+		if strike <= 0 {
+			strike = 1
+		}
+		name = fmt.Sprintf("COE (%s, digital %.1f%% acima de %.0f%%", protStr, o.DigitalReturn*100, strike*100)
+	}
+	if b := o.Barrier; b != nil {
+		switch b.Kind {
+		case KnockOutUp:
+			name += fmt.Sprintf(", KO %.0f%% rebate %.0f%%", b.Level*100, b.Rebate*100)
+		case KnockInDown:
+			name += fmt.Sprintf(", KI %.0f%%", b.Level*100)
+		}
+		if b.Observation == ObserveDiscrete {
+			name += " discreta"
+		}
+	}
+	if a := o.Autocall; a != nil {
+		name += fmt.Sprintf(", autocall %.0f%% cupom %.1f%%", a.Trigger*100, a.Coupon*100)
+	}
+	if c := o.Coupon; c != nil {
+		if c.Barrier > 0 {
+			name += fmt.Sprintf(", cupom %.1f%% se ≥ %.0f%%", c.Rate*100, c.Barrier*100)
+		} else {
+			name += fmt.Sprintf(", cupom fixo %.1f%%", c.Rate*100)
+		}
+		if c.Memory {
+			name += " com memória"
+		}
+	}
+	if o.TermMonths > 0 {
+		name += fmt.Sprintf(", %d meses", o.TermMonths)
+		switch o.Rollover {
+		case RollFallback:
+			name += ", depois ativo alternativo"
+		case RollCash:
+			name += ", depois caixa"
+		default:
+			name += ", renovado"
+		}
+	}
+	return name + ")"
+}
 
-	return StrategyResult{
-		StrategyName:     strategyName,
-		TotalInvested:    initialAmount,
-		FinalValue:       finalValue,
-		ReturnPercent:    netReturn,
-		TotalAccumulated: 0, // COE não acumula cotas, é um derivativo
+// maturityIndex devolve o primeiro pregão no ou após o vencimento do COE emitido em quotes[start].
+// Se o vencimento cair depois do fim da série, devolve o último pregão e matured=false.
+func maturityIndex(quotes []finance.Quote, start, termMonths int) (int, bool) {
+	maturity := quotes[start].Date.AddDate(0, termMonths, 0)
+	for i := start + 1; i < len(quotes); i++ {
+		if !quotes[i].Date.Before(maturity) {
+			return i, true
+		}
+	}
+	return len(quotes) - 1, false
+}
+
+// pathReturn devolve a variação do ativo entre o primeiro e o último ponto do caminho
+func pathReturn(path []finance.Quote) float64 {
+	startPrice := path[0].Close
+	if startPrice == 0 {
+		return 0
+	}
+	return (path[len(path)-1].Close - startPrice) / startPrice
+}
+
+// averageReturn devolve a variação da média das observações mensais (incluindo o vencimento)
+// em relação ao preço inicial, como nos COEs de média asiática
+func averageReturn(path []finance.Quote) float64 {
+	startPrice := path[0].Close
+	idx := observationIndexes(path, 1, true)
+	if startPrice == 0 || len(idx) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, i := range idx {
+		sum += path[i].Close
+	}
+	return sum/float64(len(idx))/startPrice - 1
+}
+
+// fallbackReturn devolve a variação do ativo alternativo entre duas datas (0 sem dados, como caixa)
+func fallbackReturn(fallback []finance.Quote, from, to time.Time) float64 {
+	startPrice, ok := finance.ValueOn(fallback, from)
+	if !ok || startPrice == 0 {
+		return 0
 	}
+	endPrice, _ := finance.ValueOn(fallback, to)
+	return (endPrice - startPrice) / startPrice
 }
@@ -0,0 +1,179 @@
+package calculator
+
+import (
+	"dca-platform/pkg/finance"
+	"math"
+	"testing"
+	"time"
+)
+
+// monthlyQuotes monta uma série com um fechamento no primeiro dia de cada mês a partir de jan/2020
+func monthlyQuotes(closes ...float64) []finance.Quote {
+	quotes := make([]finance.Quote, len(closes))
+	for i, c := range closes {
+		quotes[i] = finance.Quote{Date: time.Date(2020, time.January+time.Month(i), 1, 0, 0, 0, 0, time.UTC), Close: c}
+	}
+	return quotes
+}
+
+func TestCOERollover(t *testing.T) {
+	closes := []float64{100, 110, 120, 130, 140, 150, 160}
+	fallback := []finance.Quote{
+		{Date: time.Date(2020, time.April, 1, 0, 0, 0, 0, time.UTC), Close: 50},
+		{Date: time.Date(2020, time.July, 1, 0, 0, 0, 0, time.UTC), Close: 100},
+	}
+	tests := []struct {
+		name      string
+		rollover  COERollover
+		wantNotes int
+		wantValue float64
+	}{
+		{"renovado", RollNewNote, 2, 1600},
+		{"ativo alternativo", RollFallback, 1, 2600},
+		{"caixa", RollCash, 1, 1300},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := COEOptions{Participation: 1, TermMonths: 3, Rollover: tt.rollover, Fallback: fallback}
+			res := CalculateCOEWithOptions(monthlyQuotes(closes...), 1000, opts)
+			if len(res.COENotes) != tt.wantNotes {
+				t.Fatalf("%d COEs, esperado %d", len(res.COENotes), tt.wantNotes)
+			}
+			if !res.COENotes[0].Matured {
+				t.Error("primeiro COE deveria ter vencido")
+			}
+			if math.Abs(res.FinalValue-tt.wantValue) > 1e-6 {
+				t.Errorf("valor final = %.4f, esperado %.4f", res.FinalValue, tt.wantValue)
+			}
+		})
+	}
+}
+
+func TestCOEBarriers(t *testing.T) {
+	tests := []struct {
+		name       string
+		barrier    COEBarrier
+		protected  bool
+		closes     []float64
+		wantReturn float64 // Retorno do COE (%)
+		wantHit    bool
+	}{
+		{"knock-out paga o rebate", COEBarrier{Kind: KnockOutUp, Level: 1.30, Rebate: 0.05}, false, []float64{100, 135, 110}, 5, true},
+		{"knock-out não atingido", COEBarrier{Kind: KnockOutUp, Level: 1.30, Rebate: 0.05}, false, []float64{100, 125, 110}, 10, false},
+		{"knock-in repassa a queda", COEBarrier{Kind: KnockInDown, Level: 0.70}, true, []float64{100, 65, 90}, -10, true},
+		{"knock-in com alta no vencimento", COEBarrier{Kind: KnockInDown, Level: 0.70}, true, []float64{100, 65, 110}, 10, true},
+		{"knock-in não atingido mantém a proteção", COEBarrier{Kind: KnockInDown, Level: 0.70}, true, []float64{100, 75, 90}, 0, false},
+		{"knock-in discreto ignora datas fora da observação", COEBarrier{Kind: KnockInDown, Level: 0.70, Observation: ObserveDiscrete, ObservationMonths: 2}, true, []float64{100, 65, 90, 95}, 0, false},
+		{"knock-in discreto no vencimento", COEBarrier{Kind: KnockInDown, Level: 0.70, Observation: ObserveDiscrete, ObservationMonths: 2}, true, []float64{100, 90, 90, 60}, -40, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			barrier := tt.barrier
+			opts := COEOptions{Protected: tt.protected, Participation: 1, Barrier: &barrier}
+			res := CalculateCOEWithOptions(monthlyQuotes(tt.closes...), 1000, opts)
+			if len(res.COENotes) != 1 {
+				t.Fatalf("%d COEs, esperado 1", len(res.COENotes))
+			}
+			note := res.COENotes[0]
+			if math.Abs(note.Return-tt.wantReturn) > 1e-9 {
+				t.Errorf("retorno = %.4f%%, esperado %.4f%%", note.Return, tt.wantReturn)
+			}
+			if note.BarrierHit != tt.wantHit {
+				t.Errorf("barreira atingida = %v, esperado %v", note.BarrierHit, tt.wantHit)
+			}
+		})
+	}
+}
+
+func TestCOEAutocallAndCoupons(t *testing.T) {
+	tests := []struct {
+		name       string
+		opts       COEOptions
+		closes     []float64
+		wantReturn float64 // Retorno do COE (%)
+		wantCalled bool
+	}{
+		{
+			"autocall na primeira observação",
+			COEOptions{Participation: 1, Autocall: &COEAutocall{Trigger: 1.0, Coupon: 0.04, ObservationMonths: 6}, Rollover: RollCash},
+			[]float64{100, 95, 95, 95, 95, 95, 105, 150}, 4, true,
+		},
+		{
+			"autocall na segunda observação",
+			COEOptions{Participation: 1, Autocall: &COEAutocall{Trigger: 1.0, Coupon: 0.04, ObservationMonths: 3}, Rollover: RollCash},
+			[]float64{100, 95, 95, 90, 95, 95, 100, 150}, 8, true,
+		},
+		{
+			"autocall não atingido",
+			COEOptions{Protected: true, Participation: 1, Autocall: &COEAutocall{Trigger: 1.0, Coupon: 0.04, ObservationMonths: 3}},
+			[]float64{100, 95, 95, 90, 95, 95, 99, 98}, 0, false,
+		},
+		{
+			"cupom fixo",
+			COEOptions{Protected: true, Coupon: &COECoupon{Rate: 0.02, ObservationMonths: 3}},
+			[]float64{100, 100, 100, 80, 100, 100, 80}, 4, false,
+		},
+		{
+			"cupom condicional sem memória",
+			COEOptions{Protected: true, Coupon: &COECoupon{Rate: 0.02, ObservationMonths: 3, Barrier: 1.0}},
+			[]float64{100, 100, 100, 90, 100, 100, 105, 100, 100, 100}, 4, false,
+		},
+		{
+			"cupom condicional com memória",
+			COEOptions{Protected: true, Coupon: &COECoupon{Rate: 0.02, ObservationMonths: 3, Barrier: 1.0, Memory: true}},
+			[]float64{100, 100, 100, 90, 100, 100, 105, 100, 100, 100}, 6, false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := CalculateCOEWithOptions(monthlyQuotes(tt.closes...), 1000, tt.opts)
+			if len(res.COENotes) != 1 {
+				t.Fatalf("%d COEs, esperado 1", len(res.COENotes))
+			}
+			note := res.COENotes[0]
+			if math.Abs(note.Return-tt.wantReturn) > 1e-9 {
+				t.Errorf("retorno = %.4f%%, esperado %.4f%%", note.Return, tt.wantReturn)
+			}
+			if note.Called != tt.wantCalled {
+				t.Errorf("autocall = %v, esperado %v", note.Called, tt.wantCalled)
+			}
+			if math.Abs(res.FinalValue-1000*(1+tt.wantReturn/100)) > 1e-6 {
+				t.Errorf("valor final = %.4f, inconsistente com o retorno", res.FinalValue)
+			}
+		})
+	}
+}
+
+func TestCOEPayoffs(t *testing.T) {
+	tests := []struct {
+		name       string
+		opts       COEOptions
+		closes     []float64
+		wantReturn float64 // Retorno do COE (%)
+	}{
+		{"ponta a ponta", COEOptions{Participation: 1}, []float64{100, 110, 120}, 20},
+		{"participação parcial", COEOptions{Participation: 0.5}, []float64{100, 120}, 10},
+		{"teto", COEOptions{Participation: 1, Cap: 0.10}, []float64{100, 150}, 10},
+		{"protegido na queda", COEOptions{Protected: true, Participation: 1}, []float64{100, 80}, 0},
+		{"sem proteção na queda", COEOptions{Participation: 1}, []float64{100, 80}, -20},
+		{"asiático", COEOptions{Participation: 1, Payoff: PayoffAsian}, []float64{100, 110, 120, 130}, 20},
+		{"digital acima do strike", COEOptions{Protected: true, Payoff: PayoffDigital, DigitalReturn: 0.10}, []float64{100, 90, 101}, 10},
+		{"digital abaixo, protegido", COEOptions{Protected: true, Payoff: PayoffDigital, DigitalReturn: 0.10}, []float64{100, 95}, 0},
+		{"digital abaixo, sem proteção", COEOptions{Payoff: PayoffDigital, DigitalReturn: 0.10}, []float64{100, 95}, -5},
+		{"digital com strike 110%", COEOptions{Protected: true, Payoff: PayoffDigital, Strike: 1.10, DigitalReturn: 0.10}, []float64{100, 105}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := CalculateCOEWithOptions(monthlyQuotes(tt.closes...), 1000, tt.opts)
+			if len(res.COENotes) != 1 {
+				t.Fatalf("%d COEs, esperado 1", len(res.COENotes))
+			}
+			if got := res.COENotes[0].Return; math.Abs(got-tt.wantReturn) > 1e-9 {
+				t.Errorf("retorno = %.4f%%, esperado %.4f%%", got, tt.wantReturn)
+			}
+			if math.Abs(res.FinalValue-1000*(1+tt.wantReturn/100)) > 1e-6 {
+				t.Errorf("valor final = %.4f, inconsistente com o retorno", res.FinalValue)
+			}
+		})
+	}
+}
@@ -0,0 +1,16 @@
+package calculator
+
+// TradeCosts descreve os custos de cada ordem de compra
+type TradeCosts struct {
+	Brokerage float64 `json:"brokerage,omitempty"` // Corretagem fixa por ordem, na moeda do ativo
+	Fee       float64 `json:"fee,omitempty"`       // Emolumentos/spread sobre o valor da ordem (0.0003 = 0,03%)
+}
+
+// net devolve o valor efetivamente aplicado no ativo depois dos custos da ordem
+func (c TradeCosts) net(amount float64) float64 {
+	net := amount*(1-c.Fee) - c.Brokerage
+	if net < 0 {
+		return 0
+	}
+	return net
+}
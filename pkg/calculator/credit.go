@@ -0,0 +1,77 @@
+package calculator
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// FGCLimit é a garantia do FGC por CPF e por instituição (R$). Cobre CDB, LCI, LCA e poupança, mas não COE.
+const FGCLimit = 250000.0
+
+// CreditRisk descreve o risco de crédito do emissor de um produto
+type CreditRisk struct {
+	Issuer             string
+	DefaultProbability float64 // Probabilidade anual de default (0.01 = 1%)
+	Recovery           float64 // Fração recuperada da parte sem garantia no default (0.40 = 40%)
+	Guaranteed         float64 // Valor coberto pelo FGC, recebido integralmente no default (na moeda do resultado)
+}
+
+// ExpectedValue devolve o valor esperado de um saldo exposto ao emissor durante years anos.
+// Sem default recebe-se o saldo; com default, a parte garantida mais a recuperação do restante.
+func (c CreditRisk) ExpectedValue(value, years float64) float64 {
+	if years <= 0 || c.DefaultProbability <= 0 {
+		return value
+	}
+	return c.expected(value, math.Pow(1-c.DefaultProbability, years))
+}
+
+// expected devolve o valor esperado do saldo dada a probabilidade de o emissor não quebrar
+func (c CreditRisk) expected(value, survival float64) float64 {
+	covered := math.Min(value, c.Guaranteed)
+	recovered := covered + (value-covered)*c.Recovery
+	return value*survival + recovered*(1-survival)
+}
+
+// survival devolve a probabilidade de o emissor não quebrar durante a exposição. Com aportes, cada um
+// fica exposto só da sua data até to e a probabilidade é a média ponderada pelo valor aportado;
+// sem aportes (ex: COE), o saldo inteiro fica exposto de from a to.
+func (c CreditRisk) survival(contributions []Contribution, from, to time.Time) float64 {
+	if c.DefaultProbability <= 0 {
+		return 1
+	}
+	surviving := func(start time.Time) float64 {
+		return math.Pow(1-c.DefaultProbability, math.Max(yearsBetween(start, to), 0))
+	}
+	var total, weighted float64
+	for _, ct := range contributions {
+		total += ct.Amount
+		weighted += ct.Amount * surviving(ct.Date)
+	}
+	if total <= 0 {
+		return surviving(from)
+	}
+	return weighted / total
+}
+
+// ApplyCreditRisk preenche o emissor e o retorno ajustado pela perda esperada de um resultado
+// exposto ao emissor entre as datas informadas. Os aportes de res.Contributions contam só a partir
+// da sua data: num DCA, o dinheiro aportado no fim da janela quase não corre o risco do emissor.
+func ApplyCreditRisk(res StrategyResult, risk CreditRisk, from, to time.Time) StrategyResult {
+	res.Issuer = risk.Issuer
+	res.CreditAdjustedValue = risk.expected(res.FinalValue, risk.survival(res.Contributions, from, to))
+	if res.TotalInvested > 0 {
+		res.CreditAdjustedReturn = (res.CreditAdjustedValue - res.TotalInvested) / res.TotalInvested * 100
+	}
+	return res
+}
+
+// FGCNotice avisa quando o saldo final passa da garantia do FGC ("" dentro da garantia ou sem FGC).
+// currency é a moeda do resultado, em que Guaranteed foi convertido.
+func (c CreditRisk) FGCNotice(res StrategyResult, currency string) string {
+	if c.Guaranteed <= 0 || res.FinalValue <= c.Guaranteed {
+		return ""
+	}
+	return fmt.Sprintf("%s: saldo final de %.2f %s acima da garantia do FGC (R$ %.0f mil por CPF e instituição) em %s",
+		res.StrategyName, res.FinalValue, currency, FGCLimit/1000, c.Issuer)
+}
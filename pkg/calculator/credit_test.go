@@ -0,0 +1,101 @@
+package calculator
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCreditExpectedValue(t *testing.T) {
+	tests := []struct {
+		name  string
+		risk  CreditRisk
+		value float64
+		years float64
+		want  float64
+	}{
+		{"sem probabilidade de default", CreditRisk{Recovery: 0.4}, 1000, 1, 1000},
+		{"sem exposição", CreditRisk{DefaultProbability: 0.1, Recovery: 0.4}, 1000, 0, 1000},
+		// 90% de receber tudo, 10% de recuperar 40%
+		{"um ano", CreditRisk{DefaultProbability: 0.1, Recovery: 0.4}, 1000, 1, 900 + 40},
+		{"dois anos", CreditRisk{DefaultProbability: 0.1, Recovery: 0.4}, 1000, 2, 810 + 0.19*400},
+		{"sem recuperação", CreditRisk{DefaultProbability: 0.1}, 1000, 1, 900},
+		// No default, os 250 garantidos voltam inteiros e o restante com a recuperação
+		{"parte garantida", CreditRisk{DefaultProbability: 0.1, Recovery: 0.4, Guaranteed: 250}, 1000, 1, 900 + 0.1*(250+750*0.4)},
+		{"saldo todo garantido", CreditRisk{DefaultProbability: 0.1, Recovery: 0.4, Guaranteed: 2000}, 1000, 1, 1000},
+	}
+	for _, tt := range tests {
+		if got := tt.risk.ExpectedValue(tt.value, tt.years); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("%s: valor esperado = %.4f, esperado %.4f", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestApplyCreditRiskWeightsContributions(t *testing.T) {
+	from := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, 730) // Dois anos de 365 dias
+	risk := CreditRisk{Issuer: "Banco X", DefaultProbability: 0.1, Recovery: 0.4}
+	tests := []struct {
+		name          string
+		contributions []Contribution
+		wantSurvival  float64
+	}{
+		{"sem aportes, janela inteira", nil, 0.81},
+		{"aporte único no início", []Contribution{{from, 1000}}, 0.81},
+		{"metade no início, metade no fim", []Contribution{{from, 500}, {to, 500}}, (0.81 + 1) / 2},
+		{"aportes anuais", []Contribution{{from, 500}, {from.AddDate(0, 0, 365), 250}, {to, 250}}, (500*0.81 + 250*0.9 + 250) / 1000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := StrategyResult{TotalInvested: 1000, FinalValue: 1200, Contributions: tt.contributions}
+			res = ApplyCreditRisk(res, risk, from, to)
+			want := 1200*tt.wantSurvival + 1200*0.4*(1-tt.wantSurvival)
+			if res.Issuer != "Banco X" || math.Abs(res.CreditAdjustedValue-want) > 1e-9 {
+				t.Errorf("emissor %q, valor ajustado %.4f; esperado Banco X e %.4f", res.Issuer, res.CreditAdjustedValue, want)
+			}
+			if wantRet := (want - 1000) / 1000 * 100; math.Abs(res.CreditAdjustedReturn-wantRet) > 1e-9 {
+				t.Errorf("retorno ajustado = %.4f%%, esperado %.4f%%", res.CreditAdjustedReturn, wantRet)
+			}
+		})
+	}
+}
+
+func TestDCAExposureIsShorterThanLumpSum(t *testing.T) {
+	quotes := monthlyQuotes(100, 100, 100, 100, 100, 100, 100, 100, 100, 100, 100, 100, 100)
+	risk := CreditRisk{DefaultProbability: 0.05}
+	from, to := quotes[0].Date, quotes[len(quotes)-1].Date
+
+	dca := ApplyCreditRisk(CalculateDCA(quotes, 0, 100, Monthly), risk, from, to)
+	ls := ApplyCreditRisk(CalculateLumpSum(quotes, 1300, "LS"), risk, from, to)
+	if len(dca.Contributions) != 13 || len(ls.Contributions) != 1 {
+		t.Fatalf("%d e %d aportes, esperado 13 e 1", len(dca.Contributions), len(ls.Contributions))
+	}
+	dcaLoss := 1 - dca.CreditAdjustedValue/dca.FinalValue
+	lsLoss := 1 - ls.CreditAdjustedValue/ls.FinalValue
+	if math.Abs(lsLoss-0.05*1.0) > 0.001 || dcaLoss >= lsLoss*0.6 {
+		t.Errorf("perda esperada do DCA %.4f, do Lump Sum %.4f; esperado cerca de metade no DCA", dcaLoss, lsLoss)
+	}
+}
+
+func TestFGCNotice(t *testing.T) {
+	res := StrategyResult{StrategyName: "CDB Banco X", FinalValue: 300000}
+	tests := []struct {
+		name       string
+		guaranteed float64
+		wantNotice bool
+	}{
+		{"acima da garantia", FGCLimit, true},
+		{"dentro da garantia", 400000, false},
+		{"sem FGC", 0, false},
+	}
+	for _, tt := range tests {
+		notice := CreditRisk{Issuer: "Banco X", Guaranteed: tt.guaranteed}.FGCNotice(res, "BRL")
+		if (notice != "") != tt.wantNotice {
+			t.Errorf("%s: aviso %q", tt.name, notice)
+		}
+		if tt.wantNotice && (!strings.Contains(notice, "300000.00 BRL") || !strings.Contains(notice, "Banco X")) {
+			t.Errorf("%s: aviso sem o saldo ou o emissor: %q", tt.name, notice)
+		}
+	}
+}
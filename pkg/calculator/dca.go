@@ -8,11 +8,41 @@ import (
 
 // StrategyResult armazena o resultado de uma estratégia
 type StrategyResult struct {
-	StrategyName    string
-	TotalInvested   float64
-	FinalValue      float64
-	ReturnPercent   float64
+	StrategyName     string
+	TotalInvested    float64
+	FinalValue       float64
+	ReturnPercent    float64
 	TotalAccumulated float64 // Qtd de ativo (BTC, Ouro onças, etc)
+
+	DividendsReceived float64        // Proventos líquidos recebidos (modos reinvest/cash)
+	CashBalance       float64        // Proventos (ou prêmios de opções) em caixa, já incluídos no FinalValue
+	TradingCosts      float64        // Corretagem e taxas pagas nas compras, incluídas no TotalInvested
+	Income            *IncomeReport  // Renda passiva por ano e projeção (nil sem proventos)
+	COENotes          []COENote      // COEs individuais da simulação (estratégias COE)
+	AnnualizedReturn  float64        // Retorno efetivo ao ano (%), preenchido pelas estratégias COE
+	HoldReturn        float64        // Retorno de manter o ativo objeto na mesma janela (%), para comparação
+	OptionPremiums    float64        // Prêmios líquidos de opções: recebidos (+) nas calls vendidas, pagos (-) nas puts compradas
+	COEValuation      *COEValuation  // Valor justo do primeiro COE da janela (nil sem taxa de desconto)
+	Notices           []string       // Avisos da estratégia, como dados auxiliares indisponíveis
+	Contributions     []Contribution `json:"-"` // Aportes com data (DCA e Lump Sum), para a exposição ao emissor
+
+	// Risco de crédito do emissor, preenchido por ApplyCreditRisk
+	Issuer               string
+	CreditAdjustedValue  float64 // Valor final esperado descontada a perda esperada por default
+	CreditAdjustedReturn float64 // Retorno esperado ajustado ao risco de crédito (%)
+
+	// Visão na moeda do investidor, preenchida quando os aportes são feitos em outra moeda
+	LocalCurrency      string
+	LocalInvested      float64
+	LocalFinalValue    float64
+	LocalReturnPercent float64
+	FXEffectPercent    float64 // Diferença de retorno (p.p.) explicada pelo câmbio e custos de remessa
+}
+
+// Contribution é um aporte da estratégia, na moeda do resultado
+type Contribution struct {
+	Date   time.Time
+	Amount float64
 }
 
 // Frequency define a frequência de investimento
@@ -26,59 +56,53 @@ const (
 
 // CalculateDCA calcula o retorno de uma estratégia DCA
 func CalculateDCA(quotes []finance.Quote, initialAmount float64, amountPerPeriod float64, freq Frequency) StrategyResult {
+	return CalculateDCAWithOptions(quotes, initialAmount, amountPerPeriod, freq, DCAOptions{})
+}
+
+// CalculateDCAWithOptions calcula o retorno de uma estratégia DCA considerando proventos e preço de execução
+func CalculateDCAWithOptions(quotes []finance.Quote, initialAmount float64, amountPerPeriod float64, freq Frequency, opts DCAOptions) StrategyResult {
 	var totalInvested float64
 	var totalAccumulated float64
+	var tradingCosts float64
+	var contributions []Contribution
 	
 	if len(quotes) == 0 {
 		return StrategyResult{StrategyName: "DCA Bitcoin (Sem dados)"}
 	}
 
-	// Compra Inicial (Lump Sum parcial)
-	if initialAmount > 0 {
-		bought := initialAmount / quotes[0].Close
-		totalAccumulated += bought
-		totalInvested += initialAmount
-	}
-
+	book := newDividendBook(opts)
+	exec := newExecutor(opts)
 	lastPurchaseDate := time.Time{}
 
-	// Compra Recorrente (DCA)
-	if amountPerPeriod > 0 {
-		for _, q := range quotes {
-			shouldBuy := false
-	
-			if lastPurchaseDate.IsZero() {
-				shouldBuy = true
-			} else {
-				switch freq {
-				case Daily:
-					// Compra todo dia que tiver dados
-					shouldBuy = true
-				case Weekly:
-					// Se passou 7 dias ou mais desde a ultima compra
-					if q.Date.Sub(lastPurchaseDate).Hours() >= 24*7 {
-						shouldBuy = true
-					}
-				case Monthly:
-					// Se mudou o mês
-					if q.Date.Month() != lastPurchaseDate.Month() || q.Date.Year() != lastPurchaseDate.Year() {
-						shouldBuy = true
-					}
-				}
-			}
-	
-			if shouldBuy {
-				bought := amountPerPeriod / q.Close
-				totalAccumulated += bought
-				totalInvested += amountPerPeriod
-				lastPurchaseDate = q.Date
-			}
+	for i, q := range quotes {
+		price := exec.buy(q)
+
+		// Proventos com data-com até hoje são pagos sobre as cotas já em carteira
+		totalAccumulated += book.settle(q.Date, totalAccumulated, price)
+
+		// Compra Inicial (Lump Sum parcial)
+		if i == 0 && initialAmount > 0 {
+			net := opts.Costs.net(initialAmount)
+			totalAccumulated += net / price
+			totalInvested += initialAmount
+			tradingCosts += initialAmount - net
+			contributions = append(contributions, Contribution{Date: q.Date, Amount: initialAmount})
+		}
+
+		// Compra Recorrente (DCA)
+		if amountPerPeriod > 0 && isPurchaseDate(freq, lastPurchaseDate, q.Date) {
+			net := opts.Costs.net(amountPerPeriod)
+			totalAccumulated += net / price
+			totalInvested += amountPerPeriod
+			tradingCosts += amountPerPeriod - net
+			contributions = append(contributions, Contribution{Date: q.Date, Amount: amountPerPeriod})
+			lastPurchaseDate = q.Date
 		}
 	}
 	
 	// Valor final = acumulado * ultimo preço
-	lastPrice := quotes[len(quotes)-1].Close
-	finalValue := totalAccumulated * lastPrice
+	lastPrice := exec.value(quotes[len(quotes)-1])
+	finalValue := totalAccumulated*lastPrice + book.cash
 	
 	ret := 0.0
 	if totalInvested > 0 {
@@ -93,33 +117,52 @@ func CalculateDCA(quotes []finance.Quote, initialAmount float64, amountPerPeriod
 	}
 
 	return StrategyResult{
-		StrategyName:     name,
-		TotalInvested:    totalInvested,
-		FinalValue:       finalValue,
-		ReturnPercent:    ret,
-		TotalAccumulated: totalAccumulated,
+		StrategyName:      name,
+		TotalInvested:     totalInvested,
+		FinalValue:        finalValue,
+		ReturnPercent:     ret,
+		TotalAccumulated:  totalAccumulated,
+		DividendsReceived: book.received,
+		CashBalance:       book.cash,
+		TradingCosts:      tradingCosts,
+		Income:            incomeReport(book.payments, quotes[len(quotes)-1].Date, totalAccumulated, totalInvested),
+		Contributions:     contributions,
 	}
 }
 
+// isPurchaseDate indica se a data corresponde a um novo aporte recorrente
+func isPurchaseDate(freq Frequency, lastPurchaseDate, date time.Time) bool {
+	if lastPurchaseDate.IsZero() {
+		return true
+	}
+
+	switch freq {
+	case Daily:
+		// Compra todo dia que tiver dados
+		return true
+	case Weekly:
+		// Se passou 7 dias ou mais desde a ultima compra
+		return date.Sub(lastPurchaseDate).Hours() >= 24*7
+	case Monthly:
+		// Se mudou o mês
+		return date.Month() != lastPurchaseDate.Month() || date.Year() != lastPurchaseDate.Year()
+	}
+	return false
+}
+
 // CalculateLumpSum calcula o retorno de um investimento único no início
 func CalculateLumpSum(quotes []finance.Quote, totalAmount float64, name string) StrategyResult {
+	return CalculateLumpSumWithOptions(quotes, totalAmount, name, DCAOptions{})
+}
+
+// CalculateLumpSumWithOptions calcula o investimento único considerando proventos
+func CalculateLumpSumWithOptions(quotes []finance.Quote, totalAmount float64, name string, opts DCAOptions) StrategyResult {
 	if len(quotes) == 0 {
 		return StrategyResult{StrategyName: name + " (Sem dados)"}
 	}
 
-	firstPrice := quotes[0].Close
-	lastPrice := quotes[len(quotes)-1].Close
-
-	accumulated := totalAmount / firstPrice
-	finalValue := accumulated * lastPrice
-
-	ret := (finalValue - totalAmount) / totalAmount * 100
-
-	return StrategyResult{
-		StrategyName:     name,
-		TotalInvested:    totalAmount,
-		FinalValue:       finalValue,
-		ReturnPercent:    ret,
-		TotalAccumulated: accumulated,
-	}
+	// Um investimento único é um DCA só com o aporte inicial
+	res := CalculateDCAWithOptions(quotes, totalAmount, 0, Monthly, opts)
+	res.StrategyName = name
+	return res
 }
@@ -0,0 +1,81 @@
+package calculator
+
+import (
+	"dca-platform/pkg/finance"
+	"time"
+)
+
+// DividendMode define como os proventos entram no cálculo de uma estratégia
+type DividendMode string
+
+const (
+	DividendsIgnore   DividendMode = ""         // Só a variação do preço de fechamento
+	DividendsAdjusted DividendMode = "adjusted" // Retorno total pelo fechamento ajustado (adjclose)
+	DividendsReinvest DividendMode = "reinvest" // Proventos recomprados no próprio ativo na data-com
+	DividendsCash     DividendMode = "cash"     // Proventos acumulados em caixa, sem reinvestir
+)
+
+// DCAOptions reúne os parâmetros opcionais das estratégias de compra
+type DCAOptions struct {
+	Dividends    []finance.Dividend // Proventos do ativo, na mesma moeda das cotações
+	DividendMode DividendMode
+	Withholding  Withholding // Imposto retido na fonte sobre os proventos
+
+	Execution ExecutionPrice // Preço de execução das compras
+	Seed      int64          // Semente do modo ExecRandom (0 = padrão fixo)
+	Costs     TradeCosts     // Corretagem e taxas de cada compra
+}
+
+// dividendBook acompanha o pagamento dos proventos ao longo da simulação
+type dividendBook struct {
+	events   []finance.Dividend
+	mode     DividendMode
+	tax      Withholding
+	next     int
+	received float64 // Total de proventos líquidos recebidos (modos reinvest/cash)
+	cash     float64 // Proventos guardados em caixa (modo cash)
+	payments []incomePayment
+}
+
+// newDividendBook registra os proventos em qualquer modo, para o relatório de renda;
+// o modo decide apenas se eles mudam a posição
+func newDividendBook(opts DCAOptions) *dividendBook {
+	return &dividendBook{events: opts.Dividends, mode: opts.DividendMode, tax: opts.Withholding}
+}
+
+// settle registra os proventos com data-com até date sobre as cotas em carteira, já descontado
+// o imposto retido, e devolve as cotas recompradas (modo reinvest). Nos modos ignore e adjusted
+// os proventos só entram no relatório de renda: no ajustado já estão embutidos no preço.
+func (b *dividendBook) settle(date time.Time, units float64, price float64) float64 {
+	var bought float64
+	for b.next < len(b.events) && !b.events[b.next].Date.After(date) {
+		event := b.events[b.next]
+		held := units + bought
+		gross := event.Amount * held
+		tax := b.tax.tax(gross)
+		amount := gross - tax
+		if held > 0 {
+			b.payments = append(b.payments, incomePayment{
+				date:     event.Date,
+				perShare: amount / held,
+				gross:    gross,
+				tax:      tax,
+			})
+		}
+
+		switch b.mode {
+		case DividendsReinvest:
+			b.received += amount
+			if price > 0 {
+				bought += amount / price
+			} else {
+				b.cash += amount
+			}
+		case DividendsCash:
+			b.received += amount
+			b.cash += amount
+		}
+		b.next++
+	}
+	return bought
+}
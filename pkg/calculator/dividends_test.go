@@ -0,0 +1,60 @@
+package calculator
+
+import (
+	"dca-platform/pkg/finance"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestDividendModes(t *testing.T) {
+	quotes := monthlyQuotes(100, 100, 100, 100)
+	dividends := []finance.Dividend{{Date: time.Date(2020, time.February, 15, 0, 0, 0, 0, time.UTC), Amount: 1}}
+	tests := []struct {
+		mode      DividendMode
+		wantValue float64
+		wantUnits float64
+		wantCash  float64
+	}{
+		{DividendsIgnore, 1000, 10, 0},
+		{DividendsAdjusted, 1000, 10, 0},
+		{DividendsCash, 1007, 10, 7},
+		{DividendsReinvest, 1007, 10.07, 0},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.mode), func(t *testing.T) {
+			opts := DCAOptions{Dividends: dividends, DividendMode: tt.mode, Withholding: WithholdingFor("US", 0)}
+			res := CalculateLumpSumWithOptions(quotes, 1000, "LS", opts)
+			if math.Abs(res.FinalValue-tt.wantValue) > 1e-9 || math.Abs(res.TotalAccumulated-tt.wantUnits) > 1e-9 || math.Abs(res.CashBalance-tt.wantCash) > 1e-9 {
+				t.Errorf("valor %.4f, cotas %.4f, caixa %.4f; esperado %.4f, %.4f, %.4f",
+					res.FinalValue, res.TotalAccumulated, res.CashBalance, tt.wantValue, tt.wantUnits, tt.wantCash)
+			}
+			// O relatório de renda registra os proventos em qualquer modo
+			if res.Income == nil || len(res.Income.ByYear) != 1 {
+				t.Fatalf("relatório de renda = %+v, esperado um ano", res.Income)
+			}
+			if y := res.Income.ByYear[0]; math.Abs(y.Gross-10) > 1e-9 || math.Abs(y.Tax-3) > 1e-9 {
+				t.Errorf("bruto %.4f, imposto %.4f; esperado 10 e 3", y.Gross, y.Tax)
+			}
+		})
+	}
+}
+
+func TestWithholdingFor(t *testing.T) {
+	tests := []struct {
+		country string
+		gross   float64
+		want    float64
+	}{
+		{"US", 100, 30},
+		{"us", 100, 30},
+		{"BR", 100, 15 * 0.4}, // 40% JCP a 15%, dividendos isentos
+		{"KY", 100, 0},
+		{"", 100, 0},
+	}
+	for _, tt := range tests {
+		if got := WithholdingFor(tt.country, 0.4).tax(tt.gross); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("WithholdingFor(%q) retém %.4f, esperado %.4f", tt.country, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package calculator
+
+import (
+	"dca-platform/pkg/finance"
+	"math/rand"
+)
+
+// ExecutionPrice define em que ponto do pregão as compras são executadas
+type ExecutionPrice string
+
+const (
+	ExecClose   ExecutionPrice = ""        // Preço de fechamento (padrão)
+	ExecOpen    ExecutionPrice = "open"    // Preço de abertura
+	ExecTypical ExecutionPrice = "typical" // Preço típico: (máxima + mínima + fechamento) / 3
+	ExecRandom  ExecutionPrice = "random"  // Ponto aleatório entre a mínima e a máxima do dia
+)
+
+// executor calcula os preços de compra e de avaliação de uma simulação
+type executor struct {
+	opts DCAOptions
+	rng  *rand.Rand
+}
+
+func newExecutor(opts DCAOptions) *executor {
+	e := &executor{opts: opts}
+	if opts.Execution == ExecRandom {
+		// Semente fixa por padrão para que a mesma simulação dê o mesmo resultado
+		seed := opts.Seed
+		if seed == 0 {
+			seed = 1
+		}
+		e.rng = rand.New(rand.NewSource(seed))
+	}
+	return e
+}
+
+// value devolve o preço usado para avaliar a posição (fechamento ou fechamento ajustado)
+func (e *executor) value(q finance.Quote) float64 {
+	if e.opts.DividendMode == DividendsAdjusted && q.AdjClose > 0 {
+		return q.AdjClose
+	}
+	return q.Close
+}
+
+// buy devolve o preço de execução de uma compra na data da cotação.
+// Sem dados intradiários (abertura/máxima/mínima zeradas) usa o fechamento.
+func (e *executor) buy(q finance.Quote) float64 {
+	price := q.Close
+	switch e.opts.Execution {
+	case ExecOpen:
+		if q.Open > 0 {
+			price = q.Open
+		}
+	case ExecTypical:
+		if q.High > 0 && q.Low > 0 {
+			price = (q.High + q.Low + q.Close) / 3
+		}
+	case ExecRandom:
+		if q.High > 0 && q.Low > 0 {
+			price = q.Low + e.rng.Float64()*(q.High-q.Low)
+		}
+	}
+
+	// No modo ajustado, aplica ao preço de execução o mesmo fator do fechamento
+	if e.opts.DividendMode == DividendsAdjusted && q.AdjClose > 0 && q.Close > 0 {
+		price *= q.AdjClose / q.Close
+	}
+	return price
+}
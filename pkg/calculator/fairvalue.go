@@ -0,0 +1,281 @@
+package calculator
+
+import (
+	"dca-platform/pkg/finance"
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+)
+
+const (
+	tradingDaysPerYear = 252  // Pregões por ano, para anualizar a volatilidade
+	volatilityWindow   = 252  // Pregões anteriores à emissão usados na volatilidade histórica
+	minVolatilityDays  = 20   // Retornos diários abaixo dos quais a volatilidade é considerada pouco confiável
+	monteCarloPaths    = 2000 // Caminhos simulados para estruturas sem fórmula fechada
+)
+
+// OptionLeg é uma opção da estrutura que replica o COE
+type OptionLeg struct {
+	Name     string
+	Quantity float64 // Quantidade por unidade de principal (negativa = vendida)
+	Strike   float64 // % do preço inicial
+	Value    float64 // Valor da perna (% do principal)
+}
+
+// COEValuation decompõe o COE na emissão em um título prefixado sem cupom (o principal)
+// e uma estrutura de opções precificada por Black-Scholes com volatilidade histórica.
+// Todos os valores em % do principal.
+type COEValuation struct {
+	Rate       float64 // Taxa pré/CDI ao ano usada no desconto (%)
+	Volatility float64 // Volatilidade histórica anualizada do ativo objeto (%)
+	VolDays    int     // Retornos diários até a emissão usados na volatilidade
+	TermYears  float64
+
+	ZeroCoupon  float64     // Valor presente do principal
+	Options     float64     // Valor da estrutura de opções
+	Legs        []OptionLeg // Opções da estrutura (vazio quando avaliada por Monte Carlo)
+	MonteCarlo  bool        // Estrutura sem fórmula fechada, avaliada por simulação de caminhos
+	FairValue   float64     // ZeroCoupon + Options
+	EmbeddedFee float64     // Preço pago (100%) menos o valor justo: custo implícito cobrado pelo emissor
+	AnnualFee   float64     // EmbeddedFee distribuído pelo prazo (% ao ano)
+}
+
+// HistoricalVolatility devolve a volatilidade anualizada dos retornos logarítmicos diários
+func HistoricalVolatility(quotes []finance.Quote) float64 {
+	var sum, sumSq float64
+	n := 0
+	for i := 1; i < len(quotes); i++ {
+		if quotes[i-1].Close <= 0 || quotes[i].Close <= 0 {
+			continue
+		}
+		r := math.Log(quotes[i].Close / quotes[i-1].Close)
+		sum += r
+		sumSq += r * r
+		n++
+	}
+	if n < 2 {
+		return 0
+	}
+	mean := sum / float64(n)
+	variance := (sumSq - float64(n)*mean*mean) / float64(n-1)
+	if variance < 0 {
+		return 0
+	}
+	return math.Sqrt(variance * tradingDaysPerYear)
+}
+
+// ValueCOE avalia o primeiro COE da janela, emitido em quotes[0], descontado à taxa pré ao ano informada (0.10 = 10%).
+// A volatilidade vem de opts.History; com menos de 20 pregões antes da emissão, VolDays indica a estimativa frágil.
+func ValueCOE(quotes []finance.Quote, opts COEOptions, rate float64) COEValuation {
+	if len(quotes) < 2 {
+		return COEValuation{Rate: rate * 100}
+	}
+	return opts.value(quotes, 0, rate)
+}
+
+// CalculateCOEReplication simula a carteira que replica cada COE da janela: na emissão compra o prefixado
+// e as opções pelo valor justo e aplica o custo embutido na taxa pré. No resgate, no vencimento ou antecipado
+// por autocall, prefixado e opções pagam juntos o mesmo que o COE, então a diferença para o COE é o custo
+// embutido rendendo a taxa pré até o resgate. Um COE ainda não vencido no fim da janela é avaliado pelo
+// intrínseco, como o próprio COE.
+func CalculateCOEReplication(quotes []finance.Quote, initialAmount float64, opts COEOptions, rate float64) StrategyResult {
+	if len(quotes) < 2 {
+		return StrategyResult{StrategyName: "Carteira Replicante (Sem dados)"}
+	}
+
+	var notices []string
+	value, notes := opts.simulateNotes(quotes, initialAmount, func(start, end int, outcome noteOutcome) float64 {
+		v := opts.value(quotes, start, rate)
+		if notice := v.volatilityNotice(quotes[start].Date); notice != "" {
+			notices = append(notices, notice)
+		}
+		return 1 + outcome.ret + v.EmbeddedFee/100*math.Pow(1+rate, yearsBetween(quotes[start].Date, quotes[end].Date))
+	})
+	res := opts.result(quotes, initialAmount, value, notes)
+	res.StrategyName = fmt.Sprintf("Carteira Replicante (pré %.2f%% + opções)", rate*100)
+	res.Notices = notices
+	return res
+}
+
+// volatilityNotice avisa quando a volatilidade da emissão teve poucos pregões anteriores ("" se houve o bastante)
+func (v COEValuation) volatilityNotice(issue time.Time) string {
+	if v.VolDays >= minVolatilityDays {
+		return ""
+	}
+	return fmt.Sprintf("volatilidade do COE emitido em %s estimada com só %d pregões anteriores; valor justo pouco confiável",
+		issue.Format("2006-01-02"), v.VolDays)
+}
+
+// value avalia o COE emitido em quotes[start], com a volatilidade dos pregões anteriores à emissão
+func (o COEOptions) value(quotes []finance.Quote, start int, rate float64) COEValuation {
+	issue := quotes[start].Date
+	years := yearsBetween(issue, quotes[len(quotes)-1].Date)
+	if o.TermMonths > 0 {
+		years = yearsBetween(issue, issue.AddDate(0, o.TermMonths, 0))
+	}
+	vol, days := trailingVolatility(o.History, quotes, start, volatilityWindow)
+
+	v := COEValuation{
+		Rate:       rate * 100,
+		Volatility: vol * 100,
+		VolDays:    days,
+		TermYears:  years,
+		ZeroCoupon: math.Pow(1+rate, -years) * 100,
+	}
+	if o.closedForm() {
+		v.Legs = o.legs(years, math.Log(1+rate), vol)
+		for _, leg := range v.Legs {
+			v.Options += leg.Value
+		}
+	} else {
+		v.MonteCarlo = true
+		v.Options = o.monteCarloValue(issue, years, rate, vol)*100 - v.ZeroCoupon
+	}
+
+	v.FairValue = v.ZeroCoupon + v.Options
+	v.EmbeddedFee = 100 - v.FairValue
+	if years > 0 {
+		v.AnnualFee = v.EmbeddedFee / years
+	}
+	return v
+}
+
+// closedForm indica se o COE é uma combinação de opções europeias simples (calls, puts e digitais)
+func (o COEOptions) closedForm() bool {
+	if o.Barrier != nil || o.Autocall != nil || o.Coupon != nil {
+		return false
+	}
+	return o.Payoff == PayoffPointToPoint || (o.Payoff == PayoffDigital && o.Protected)
+}
+
+// legs monta a estrutura de opções europeias equivalente ao payoff, com preço inicial normalizado em 1
+func (o COEOptions) legs(years, rate, vol float64) []OptionLeg {
+	leg := func(name string, quantity, strike, unitValue float64) OptionLeg {
+		return OptionLeg{Name: name, Quantity: quantity, Strike: strike * 100, Value: quantity * unitValue * 100}
+	}
+
+	if o.Payoff == PayoffDigital {
+		strike := o.Strike
+		if strike <= 0 {
+			strike = 1
+		}
+		return []OptionLeg{leg("Digital (cash-or-nothing) comprada", o.DigitalReturn, strike, digitalCall(1, strike, years, rate, vol))}
+	}
+
+	var legs []OptionLeg
+	if o.Participation > 0 {
+		legs = append(legs, leg("Call comprada", o.Participation, 1, blackScholes(true, 1, 1, years, rate, vol)))
+		if o.Cap > 0 {
+			// O teto é uma call vendida no preço em que a participação atinge o Cap
+			strike := 1 + o.Cap/o.Participation
+			legs = append(legs, leg("Call vendida (teto)", -o.Participation, strike, blackScholes(true, 1, strike, years, rate, vol)))
+		}
+	}
+	if !o.Protected {
+		// Sem proteção, a queda também é repassada com a participação
+		legs = append(legs, leg("Put vendida (sem proteção)", -o.Participation, 1, blackScholes(false, 1, 1, years, rate, vol)))
+	}
+	return legs
+}
+
+// monteCarloValue devolve o valor presente médio do resgate (fração do principal) simulando
+// caminhos diários do ativo objeto sob as mesmas hipóteses de Black-Scholes.
+// A semente é fixa para que a mesma simulação dê sempre o mesmo resultado.
+func (o COEOptions) monteCarloValue(issue time.Time, years, rate, vol float64) float64 {
+	var dates []time.Time
+	maturity := issue.AddDate(0, 0, int(years*365+0.5))
+	for d := issue; !d.After(maturity); d = d.AddDate(0, 0, 1) {
+		if d.Weekday() != time.Saturday && d.Weekday() != time.Sunday {
+			dates = append(dates, d)
+		}
+	}
+	if len(dates) < 2 {
+		return math.Pow(1+rate, -years)
+	}
+
+	dt := years / float64(len(dates)-1)
+	drift := (math.Log(1+rate) - vol*vol/2) * dt
+	diffusion := vol * math.Sqrt(dt)
+	rng := rand.New(rand.NewSource(1))
+	path := make([]finance.Quote, len(dates))
+
+	var total float64
+	for n := 0; n < monteCarloPaths; n++ {
+		price := 1.0
+		for i, d := range dates {
+			if i > 0 {
+				price *= math.Exp(drift + diffusion*rng.NormFloat64())
+			}
+			path[i] = finance.Quote{Date: d, Close: price}
+		}
+		out := o.payoff(path)
+		total += (1 + out.ret) * math.Pow(1+rate, -yearsBetween(issue, path[out.end].Date))
+	}
+	return total / monteCarloPaths
+}
+
+// trailingVolatility estima a volatilidade em quotes[at] com até window retornos diários terminados
+// nessa data, completando o início da janela com os pregões de history anteriores a quotes[0].
+// Nunca usa cotações posteriores a quotes[at]; devolve também quantos retornos entraram na estimativa.
+func trailingVolatility(history, quotes []finance.Quote, at, window int) (float64, int) {
+	past := quotes[:at+1]
+	if missing := window + 1 - len(past); missing > 0 {
+		var before []finance.Quote
+		for _, q := range history {
+			if q.Date.Before(quotes[0].Date) {
+				before = append(before, q)
+			}
+		}
+		if len(before) > missing {
+			before = before[len(before)-missing:]
+		}
+		past = append(append([]finance.Quote(nil), before...), past...)
+	}
+	if len(past) > window+1 {
+		past = past[len(past)-window-1:]
+	}
+	return HistoricalVolatility(past), len(past) - 1
+}
+
+// blackScholes devolve o preço de uma call ou put europeia (taxa de juros contínua, sem dividendos)
+func blackScholes(call bool, spot, strike, years, rate, vol float64) float64 {
+	df := math.Exp(-rate * years)
+	if years <= 0 || vol <= 0 {
+		// Sem incerteza, a opção vale o intrínseco sobre o preço a termo
+		forward := spot / df
+		if call {
+			return df * math.Max(forward-strike, 0)
+		}
+		return df * math.Max(strike-forward, 0)
+	}
+	d1 := (math.Log(spot/strike) + (rate+vol*vol/2)*years) / (vol * math.Sqrt(years))
+	d2 := d1 - vol*math.Sqrt(years)
+	if call {
+		return spot*normCDF(d1) - strike*df*normCDF(d2)
+	}
+	return strike*df*normCDF(-d2) - spot*normCDF(-d1)
+}
+
+// digitalCall devolve o preço de uma digital que paga 1 se o ativo terminar no strike ou acima
+func digitalCall(spot, strike, years, rate, vol float64) float64 {
+	df := math.Exp(-rate * years)
+	if years <= 0 || vol <= 0 {
+		if spot/df >= strike {
+			return df
+		}
+		return 0
+	}
+	d2 := (math.Log(spot/strike) + (rate-vol*vol/2)*years) / (vol * math.Sqrt(years))
+	return df * normCDF(d2)
+}
+
+// normCDF é a distribuição acumulada da normal padrão
+func normCDF(x float64) float64 {
+	return 0.5 * math.Erfc(-x/math.Sqrt2)
+}
+
+// yearsBetween devolve o intervalo em anos corridos entre duas datas
+func yearsBetween(from, to time.Time) float64 {
+	return to.Sub(from).Hours() / 24 / 365
+}
@@ -0,0 +1,196 @@
+package calculator
+
+import (
+	"dca-platform/pkg/finance"
+	"math"
+	"testing"
+	"time"
+)
+
+// dailyQuotes monta uma série com um fechamento por dia corrido a partir de start
+func dailyQuotes(start time.Time, closes ...float64) []finance.Quote {
+	quotes := make([]finance.Quote, len(closes))
+	for i, c := range closes {
+		quotes[i] = finance.Quote{Date: start.AddDate(0, 0, i), Close: c}
+	}
+	return quotes
+}
+
+// zigzag alterna dois preços, para uma volatilidade conhecida e constante
+func zigzag(n int, low, high float64) []float64 {
+	closes := make([]float64, n)
+	for i := range closes {
+		closes[i] = low
+		if i%2 == 1 {
+			closes[i] = high
+		}
+	}
+	return closes
+}
+
+func TestCOEValuationUsesOnlyPastVolatility(t *testing.T) {
+	issue := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+	calm := dailyQuotes(issue, append([]float64{100}, zigzag(60, 100, 101)...)...)
+	wild := dailyQuotes(issue, append([]float64{100}, zigzag(60, 60, 140)...)...)
+	history := dailyQuotes(issue.AddDate(0, 0, -40), zigzag(40, 99, 101)...)
+	opts := COEOptions{Protected: true, Participation: 1, TermMonths: 1}
+
+	// Sem histórico anterior, a emissão não empresta a volatilidade dos pregões seguintes
+	v := ValueCOE(wild, opts, 0.10)
+	if v.VolDays != 0 || v.Volatility != 0 {
+		t.Errorf("sem histórico: %d pregões, volatilidade %.4f%%; esperado 0 e 0", v.VolDays, v.Volatility)
+	}
+	if v.volatilityNotice(issue) == "" {
+		t.Error("sem histórico: esperado aviso de volatilidade pouco confiável")
+	}
+
+	opts.History = history
+	want := HistoricalVolatility(append(append([]finance.Quote(nil), history...), wild[0])) * 100
+	for name, quotes := range map[string][]finance.Quote{"calmo": calm, "volátil": wild} {
+		v := ValueCOE(quotes, opts, 0.10)
+		if v.VolDays != 40 || math.Abs(v.Volatility-want) > 1e-9 {
+			t.Errorf("%s: %d pregões, volatilidade %.4f%%; esperado 40 e %.4f%%", name, v.VolDays, v.Volatility, want)
+		}
+		if v.volatilityNotice(issue) != "" {
+			t.Errorf("%s: aviso com histórico suficiente", name)
+		}
+	}
+}
+
+func TestTrailingVolatilityWindow(t *testing.T) {
+	start := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+	history := dailyQuotes(start.AddDate(0, 0, -10), zigzag(10, 90, 110)...)
+	quotes := dailyQuotes(start, zigzag(10, 100, 101)...)
+
+	tests := []struct {
+		name     string
+		history  []finance.Quote
+		at       int
+		window   int
+		wantDays int
+		wantFrom []finance.Quote // Pregões esperados na estimativa
+	}{
+		{"só a janela", nil, 5, 20, 5, quotes[:6]},
+		{"completa com o histórico", history, 5, 8, 8, append(append([]finance.Quote(nil), history[7:]...), quotes[:6]...)},
+		{"janela cheia dispensa o histórico", history, 9, 4, 4, quotes[5:10]},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vol, days := trailingVolatility(tt.history, quotes, tt.at, tt.window)
+			if days != tt.wantDays || math.Abs(vol-HistoricalVolatility(tt.wantFrom)) > 1e-12 {
+				t.Errorf("volatilidade %.6f com %d retornos; esperado %.6f com %d", vol, days, HistoricalVolatility(tt.wantFrom), tt.wantDays)
+			}
+		})
+	}
+}
+
+func TestCOEReplicationTracksEarlyRedemption(t *testing.T) {
+	quotes := monthlyQuotes(100, 98, 97, 99, 101, 103, 110, 112, 115, 120, 118, 121, 125)
+	history := dailyQuotes(quotes[0].Date.AddDate(0, 0, -60), zigzag(60, 98, 102)...)
+	rate := 0.10
+	tests := []struct {
+		name       string
+		opts       COEOptions
+		wantCalled bool
+	}{
+		{"autocall antecipado", COEOptions{Participation: 1, TermMonths: 12, Rollover: RollCash, History: history,
+			Autocall: &COEAutocall{Trigger: 1.05, Coupon: 0.05, ObservationMonths: 6}}, true},
+		{"ponta a ponta no vencimento", COEOptions{Protected: true, Participation: 1, TermMonths: 12, Rollover: RollCash, History: history}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			coe := CalculateCOEWithOptions(quotes, 1000, tt.opts)
+			replica := CalculateCOEReplication(quotes, 1000, tt.opts, rate)
+			if len(coe.COENotes) != 1 || len(replica.COENotes) != 1 {
+				t.Fatalf("%d e %d COEs, esperado 1", len(coe.COENotes), len(replica.COENotes))
+			}
+			note := replica.COENotes[0]
+			if note.Called != tt.wantCalled || coe.COENotes[0].Called != tt.wantCalled {
+				t.Fatalf("autocall = %v, esperado %v", note.Called, tt.wantCalled)
+			}
+
+			// A replicante difere do COE só pelo custo embutido rendendo a taxa pré até o resgate
+			fee := ValueCOE(quotes, tt.opts, rate).EmbeddedFee / 100
+			want := coe.COENotes[0].Return + fee*math.Pow(1+rate, yearsBetween(note.Start, note.End))*100
+			if math.Abs(note.Return-want) > 1e-9 {
+				t.Errorf("replicante %.4f%%, COE %.4f%%; esperado %.4f%%", note.Return, coe.COENotes[0].Return, want)
+			}
+		})
+	}
+}
+
+func TestBlackScholesReferenceValues(t *testing.T) {
+	// Hull, Options, Futures and Other Derivatives: S = K = 100, T = 1, r = 5%, σ = 20%
+	if got := blackScholes(true, 100, 100, 1, 0.05, 0.20); math.Abs(got-10.4506) > 1e-4 {
+		t.Errorf("call no dinheiro = %.4f, esperado 10.4506", got)
+	}
+	if got := blackScholes(false, 100, 100, 1, 0.05, 0.20); math.Abs(got-5.5735) > 1e-4 {
+		t.Errorf("put no dinheiro = %.4f, esperado 5.5735", got)
+	}
+	// Digital: e^(-rT)·N(d2), d2 = (r - σ²/2)·T/σ = 0.15
+	if got := digitalCall(1, 1, 1, 0.05, 0.20); math.Abs(got-math.Exp(-0.05)*normCDF(0.15)) > 1e-12 {
+		t.Errorf("digital no dinheiro = %.6f, esperado %.6f", got, math.Exp(-0.05)*normCDF(0.15))
+	}
+	if got := normCDF(0.15); math.Abs(got-0.559618) > 1e-6 {
+		t.Errorf("N(0.15) = %.6f, esperado 0.559618", got)
+	}
+
+	for _, strike := range []float64{0.8, 1, 1.25} {
+		// Paridade put-call: C - P = S - K·e^(-rT)
+		call := blackScholes(true, 1, strike, 2, 0.08, 0.35)
+		put := blackScholes(false, 1, strike, 2, 0.08, 0.35)
+		if parity := 1 - strike*math.Exp(-0.08*2); math.Abs(call-put-parity) > 1e-12 {
+			t.Errorf("strike %.2f: C - P = %.6f, esperado %.6f", strike, call-put, parity)
+		}
+		// A digital é a derivada da call em relação ao strike, com sinal trocado
+		h := 1e-5
+		slope := (blackScholes(true, 1, strike-h, 2, 0.08, 0.35) - blackScholes(true, 1, strike+h, 2, 0.08, 0.35)) / (2 * h)
+		if got := digitalCall(1, strike, 2, 0.08, 0.35); math.Abs(got-slope) > 1e-6 {
+			t.Errorf("strike %.2f: digital = %.6f, esperado -dC/dK = %.6f", strike, got, slope)
+		}
+	}
+
+	// Sem volatilidade, vale o intrínseco sobre o preço a termo
+	if got := blackScholes(true, 1, 1, 1, 0.05, 0); math.Abs(got-(1-math.Exp(-0.05))) > 1e-12 {
+		t.Errorf("call sem volatilidade = %.6f, esperado %.6f", got, 1-math.Exp(-0.05))
+	}
+}
+
+func TestHistoricalVolatility(t *testing.T) {
+	start := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+	a := math.Log(1.01)
+	tests := []struct {
+		name   string
+		closes []float64
+		want   float64
+	}{
+		{"preço constante", []float64{100, 100, 100, 100}, 0},
+		{"crescimento constante", []float64{100, 101, 102.01, 103.0301}, 0},
+		// Retornos ±a com média zero: variância amostral 4a²/3
+		{"zigue-zague", []float64{100, 101, 100, 101, 100}, math.Sqrt(4 * a * a / 3 * tradingDaysPerYear)},
+		// Só os pares válidos entram: +a, +a, -a, +a, com média a/2 e variância a²
+		{"ignora preços inválidos", []float64{100, 101, 0, 100, 101, 100, 101}, a * math.Sqrt(tradingDaysPerYear)},
+		{"um retorno só", []float64{100, 101}, 0},
+	}
+	for _, tt := range tests {
+		if got := HistoricalVolatility(dailyQuotes(start, tt.closes...)); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("%s: volatilidade = %.6f, esperado %.6f", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestMonteCarloMatchesClosedForm(t *testing.T) {
+	// Call com teto: Black-Scholes (call comprada - call vendida) contra os caminhos simulados
+	issue := time.Date(2021, time.January, 4, 0, 0, 0, 0, time.UTC)
+	opts := COEOptions{Protected: true, Participation: 1, Cap: 0.25}
+	years, rate, vol := 1.0, 0.08, 0.30
+
+	closed := math.Pow(1+rate, -years)
+	for _, leg := range opts.legs(years, math.Log(1+rate), vol) {
+		closed += leg.Value / 100
+	}
+	mc := opts.monteCarloValue(issue, years, rate, vol)
+	if math.Abs(mc-closed) > 0.01 {
+		t.Errorf("Monte Carlo = %.4f, fórmula fechada = %.4f", mc, closed)
+	}
+}
@@ -0,0 +1,100 @@
+package calculator
+
+import (
+	"dca-platform/pkg/finance"
+	"fmt"
+)
+
+// FXCosts descreve os custos de remessa ao converter o aporte da moeda do investidor
+type FXCosts struct {
+	IOF    float64 // Alíquota de IOF sobre a remessa (ex: 0.011 para 1,1%)
+	Spread float64 // Spread cambial da corretora sobre a taxa de mercado (ex: 0.01 para 1%)
+}
+
+// CalculateDCALocalCurrency calcula um DCA em que os aportes são fixos na moeda do investidor
+// (ex: R$ 500 por mês em AAPL), convertidos pelo câmbio da data de cada compra.
+// quotes: Histórico do ativo na sua moeda (ex: USD)
+// fx: Câmbio em unidades da moeda local por unidade da moeda do ativo (ex: BRL por USD)
+// Os valores padrão do resultado ficam na moeda do ativo e os campos Local* na moeda do investidor.
+func CalculateDCALocalCurrency(quotes []finance.Quote, fx []finance.Quote, localCurrency string, initialAmount float64, amountPerPeriod float64, freq Frequency, costs FXCosts, opts DCAOptions) StrategyResult {
+	if len(quotes) == 0 || len(fx) == 0 {
+		return StrategyResult{StrategyName: fmt.Sprintf("DCA em %s (Sem dados)", localCurrency)}
+	}
+
+	var totalInvested, localInvested, totalAccumulated, tradingCosts float64
+	var contributions []Contribution
+	book := newDividendBook(opts)
+	exec := newExecutor(opts)
+
+	// buy converte o aporte local pelo câmbio do dia (com IOF e spread) e compra o ativo
+	buy := func(localAmount float64, q finance.Quote, price float64) {
+		rate := rateOn(fx, q)
+		if rate == 0 {
+			return
+		}
+		marketAmount := localAmount / rate
+		converted := localAmount / (1 + costs.IOF) / (rate * (1 + costs.Spread))
+		netAmount := opts.Costs.net(converted)
+
+		totalAccumulated += netAmount / price
+		tradingCosts += converted - netAmount
+		totalInvested += marketAmount
+		localInvested += localAmount
+		contributions = append(contributions, Contribution{Date: q.Date, Amount: marketAmount})
+	}
+
+	var lastPurchase finance.Quote
+	for i, q := range quotes {
+		// Proventos ficam na moeda do ativo: reinvestidos ou mantidos em caixa lá fora
+		price := exec.buy(q)
+		totalAccumulated += book.settle(q.Date, totalAccumulated, price)
+
+		if i == 0 && initialAmount > 0 {
+			buy(initialAmount, q, price)
+		}
+		if amountPerPeriod > 0 && isPurchaseDate(freq, lastPurchase.Date, q.Date) {
+			buy(amountPerPeriod, q, price)
+			lastPurchase = q
+		}
+	}
+
+	last := quotes[len(quotes)-1]
+	finalValue := totalAccumulated*exec.value(last) + book.cash
+	localFinal := finalValue * rateOn(fx, last)
+
+	ret := 0.0
+	if totalInvested > 0 {
+		ret = (finalValue - totalInvested) / totalInvested * 100
+	}
+	localRet := 0.0
+	if localInvested > 0 {
+		localRet = (localFinal - localInvested) / localInvested * 100
+	}
+
+	return StrategyResult{
+		StrategyName:       fmt.Sprintf("DCA %s em %s", string(freq), localCurrency),
+		TotalInvested:      totalInvested,
+		FinalValue:         finalValue,
+		ReturnPercent:      ret,
+		TotalAccumulated:   totalAccumulated,
+		DividendsReceived:  book.received,
+		CashBalance:        book.cash,
+		TradingCosts:       tradingCosts,
+		Income:             incomeReport(book.payments, last.Date, totalAccumulated, totalInvested),
+		LocalCurrency:      localCurrency,
+		LocalInvested:      localInvested,
+		LocalFinalValue:    localFinal,
+		LocalReturnPercent: localRet,
+		FXEffectPercent:    localRet - ret,
+		Contributions:      contributions,
+	}
+}
+
+// rateOn devolve o câmbio da data da cotação ou, na falta dele (feriado), o último anterior
+func rateOn(fx []finance.Quote, q finance.Quote) float64 {
+	if rate, ok := finance.ValueOn(fx, q.Date); ok {
+		return rate
+	}
+	// Sem câmbio anterior: usa o primeiro disponível
+	return fx[0].Close
+}
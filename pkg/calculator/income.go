@@ -0,0 +1,113 @@
+package calculator
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// Alíquotas de retenção na fonte para residentes no Brasil
+const (
+	JCPWithholding        = 0.15 // Juros sobre Capital Próprio (dividendos BR são isentos)
+	USDividendWithholding = 0.30 // Dividendos pagos por empresas americanas
+)
+
+// Withholding descreve o imposto retido na fonte sobre os proventos de um ativo
+type Withholding struct {
+	Rate     float64 // Alíquota sobre os dividendos (ex: 0.30 para EUA)
+	JCPShare float64 // Fração dos proventos paga como JCP (ações BR)
+	JCPRate  float64 // Alíquota sobre a parcela de JCP
+}
+
+// WithholdingFor devolve as regras de um residente no Brasil conforme o país do emissor (código ISO,
+// ex: "US", "BR"). ADRs de empresas brasileiras negociados em dólar seguem as regras do Brasil.
+// jcpShare é a fração dos proventos de empresas brasileiras paga como JCP.
+func WithholdingFor(issuerCountry string, jcpShare float64) Withholding {
+	switch strings.ToUpper(issuerCountry) {
+	case "BR":
+		return Withholding{JCPShare: jcpShare, JCPRate: JCPWithholding}
+	case "US":
+		return Withholding{Rate: USDividendWithholding}
+	}
+	return Withholding{}
+}
+
+// CountryForCurrency deduz o país do emissor pela moeda de negociação, para ativos sem país cadastrado
+func CountryForCurrency(currency string) string {
+	switch currency {
+	case "BRL":
+		return "BR"
+	case "USD":
+		return "US"
+	}
+	return ""
+}
+
+// tax devolve o imposto retido sobre um provento bruto
+func (w Withholding) tax(gross float64) float64 {
+	jcp := gross * w.JCPShare
+	return jcp*w.JCPRate + (gross-jcp)*w.Rate
+}
+
+// YearIncome soma os proventos recebidos em um ano
+type YearIncome struct {
+	Year  int
+	Gross float64
+	Tax   float64
+	Net   float64
+}
+
+// IncomeReport resume a renda passiva gerada por uma estratégia
+type IncomeReport struct {
+	ByYear        []YearIncome
+	YieldOnCost   float64 // % dos proventos líquidos dos últimos 12 meses sobre o total investido
+	MonthlyIncome float64 // Renda mensal líquida projetada com as cotas finais
+}
+
+// incomePayment é um provento efetivamente pago durante a simulação
+type incomePayment struct {
+	date     time.Time
+	perShare float64 // Valor líquido por cota
+	gross    float64
+	tax      float64
+}
+
+// incomeReport monta o relatório a partir dos pagamentos, das cotas finais e do total investido
+func incomeReport(payments []incomePayment, lastDate time.Time, units float64, totalInvested float64) *IncomeReport {
+	if len(payments) == 0 {
+		return nil
+	}
+
+	years := make(map[int]*YearIncome)
+	var ttmPerShare float64
+	cutoff := lastDate.AddDate(-1, 0, 0)
+
+	for _, p := range payments {
+		y := years[p.date.Year()]
+		if y == nil {
+			y = &YearIncome{Year: p.date.Year()}
+			years[p.date.Year()] = y
+		}
+		y.Gross += p.gross
+		y.Tax += p.tax
+		y.Net += p.gross - p.tax
+
+		if p.date.After(cutoff) {
+			ttmPerShare += p.perShare
+		}
+	}
+
+	report := &IncomeReport{}
+	for _, y := range years {
+		report.ByYear = append(report.ByYear, *y)
+	}
+	sort.Slice(report.ByYear, func(i, j int) bool { return report.ByYear[i].Year < report.ByYear[j].Year })
+
+	// Projeção: mesmos proventos por cota dos últimos 12 meses sobre a posição final
+	annual := ttmPerShare * units
+	report.MonthlyIncome = annual / 12
+	if totalInvested > 0 {
+		report.YieldOnCost = annual / totalInvested * 100
+	}
+	return report
+}
@@ -0,0 +1,146 @@
+package calculator
+
+import (
+	"dca-platform/pkg/finance"
+	"fmt"
+	"math"
+	"time"
+)
+
+// OverlayKind define a estratégia de opções montada sobre a posição no ativo
+type OverlayKind string
+
+const (
+	CoveredCall   OverlayKind = "covered_call"   // Venda mensal de calls sobre toda a posição
+	ProtectivePut OverlayKind = "protective_put" // Compra mensal de puts sobre toda a posição
+)
+
+// PremiumMode define o destino dos prêmios e dos ajustes das opções
+type PremiumMode string
+
+const (
+	PremiumReinvest PremiumMode = ""     // Entradas compram cotas e saídas vendem cotas
+	PremiumCash     PremiumMode = "cash" // Entradas ficam em caixa; saídas usam o caixa antes de vender cotas
+)
+
+// overlayVolWindow é o número de pregões da volatilidade histórica usada em cada rolagem
+const overlayVolWindow = 63
+
+// OverlayOptions descreve a estratégia de opções sobre a posição
+type OverlayOptions struct {
+	Kind      OverlayKind
+	Moneyness float64     // Strike em fração do preço na rolagem (1.05 = call 5% fora do dinheiro)
+	Premiums  PremiumMode // Reinvestir prêmios e ajustes (padrão) ou mantê-los em caixa
+	Rate      float64     // Taxa livre de risco ao ano do Black-Scholes (0.10 = 10%)
+
+	// History são os pregões anteriores à janela, para a volatilidade das primeiras rolagens
+	History []finance.Quote
+}
+
+// CalculateOverlay compra o ativo no início e, a cada mês, lança calls cobertas ou compra puts
+// de proteção sobre toda a posição, com vencimento na rolagem seguinte. Sem dados de opções, os
+// prêmios vêm de Black-Scholes com a volatilidade histórica dos três meses anteriores a cada rolagem,
+// completados com opts.History no início da janela. As opções são liquidadas financeiramente no
+// vencimento; a que estiver aberta no fim é avaliada a mercado.
+func CalculateOverlay(quotes []finance.Quote, initialAmount float64, opts OverlayOptions) StrategyResult {
+	if len(quotes) == 0 || quotes[0].Close <= 0 {
+		return StrategyResult{StrategyName: fmt.Sprintf("%s (Sem dados)", opts.name())}
+	}
+
+	rate := math.Log(1 + opts.Rate)
+	call := opts.Kind == CoveredCall
+	shares := initialAmount / quotes[0].Close
+	var cash, premiums float64
+
+	// settle aplica um fluxo das opções (positivo = recebido) conforme o destino escolhido
+	settle := func(flow, price float64) {
+		if flow >= 0 && opts.Premiums == PremiumCash {
+			cash += flow
+			return
+		}
+		if flow < 0 && opts.Premiums == PremiumCash {
+			fromCash := math.Min(cash, -flow)
+			cash -= fromCash
+			flow += fromCash
+		}
+		shares += flow / price
+	}
+
+	var (
+		open      bool
+		strike    float64
+		contracts float64
+		expiry    time.Time
+		shortVol  []time.Time // Rolagens precificadas com menos de minVolatilityDays retornos
+	)
+	for i, q := range quotes {
+		if open && !q.Date.Before(expiry) {
+			payoff := math.Max(strike-q.Close, 0)
+			if call {
+				// A call vendida paga a alta acima do strike
+				payoff = -math.Max(q.Close-strike, 0)
+			}
+			settle(payoff*contracts, q.Close)
+			open = false
+		}
+		if !open && i < len(quotes)-1 {
+			expiry = q.Date.AddDate(0, 1, 0)
+			strike = q.Close * opts.Moneyness
+			contracts = shares
+			vol, days := trailingVolatility(opts.History, quotes, i, overlayVolWindow)
+			if days < minVolatilityDays {
+				shortVol = append(shortVol, q.Date)
+			}
+			price := blackScholes(call, q.Close, strike, yearsBetween(q.Date, expiry), rate, vol)
+			flow := -price * contracts
+			if call {
+				flow = price * contracts
+			}
+			premiums += flow
+			settle(flow, q.Close)
+			open = true
+		}
+	}
+
+	last := quotes[len(quotes)-1]
+	value := shares*last.Close + cash
+	if open {
+		// Opção ainda aberta: avaliada a mercado com o prazo restante
+		vol, _ := trailingVolatility(opts.History, quotes, len(quotes)-1, overlayVolWindow)
+		mtm := blackScholes(call, last.Close, strike, yearsBetween(last.Date, expiry), rate, vol) * contracts
+		if call {
+			mtm = -mtm
+		}
+		value += mtm
+	}
+
+	var notices []string
+	if len(shortVol) > 0 {
+		notices = append(notices, fmt.Sprintf("prêmios de %d rolagem(ns) até %s calculados com menos de %d pregões de histórico",
+			len(shortVol), shortVol[len(shortVol)-1].Format("2006-01-02"), minVolatilityDays))
+	}
+	return StrategyResult{
+		StrategyName:     opts.name(),
+		TotalInvested:    initialAmount,
+		FinalValue:       value,
+		ReturnPercent:    (value - initialAmount) / initialAmount * 100,
+		TotalAccumulated: shares,
+		CashBalance:      cash,
+		OptionPremiums:   premiums,
+		AnnualizedReturn: annualized(value/initialAmount-1, quotes[0].Date, last.Date) * 100,
+		Notices:          notices,
+	}
+}
+
+// name monta o nome descritivo da estratégia
+func (o OverlayOptions) name() string {
+	kind := "Venda Coberta"
+	if o.Kind == ProtectivePut {
+		kind = "Put Protetora"
+	}
+	dest := "reinvestidos"
+	if o.Premiums == PremiumCash {
+		dest = "em caixa"
+	}
+	return fmt.Sprintf("%s %.0f%% mensal (prêmios %s)", kind, o.Moneyness*100, dest)
+}
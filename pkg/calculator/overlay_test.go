@@ -0,0 +1,90 @@
+package calculator
+
+import (
+	"math"
+	"testing"
+)
+
+func TestOverlayPremiumsAccrue(t *testing.T) {
+	quotes := monthlyQuotes(100, 100, 100, 100, 100)
+	history := dailyQuotes(quotes[0].Date.AddDate(0, 0, -80), zigzag(80, 98, 102)...)
+	tests := []struct {
+		name     string
+		kind     OverlayKind
+		premiums PremiumMode
+		wantSign float64 // Sinal dos prêmios: calls vendidas recebem, puts compradas pagam
+		wantCash bool
+	}{
+		{"call reinvestida", CoveredCall, PremiumReinvest, 1, false},
+		{"call em caixa", CoveredCall, PremiumCash, 1, true},
+		{"put reinvestida", ProtectivePut, PremiumReinvest, -1, false},
+		{"put em caixa", ProtectivePut, PremiumCash, -1, false}, // Sem caixa, o prêmio pago vende cotas
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := OverlayOptions{Kind: tt.kind, Moneyness: 1.05, Premiums: tt.premiums, Rate: 0.10, History: history}
+			if tt.kind == ProtectivePut {
+				opts.Moneyness = 0.95
+			}
+			res := CalculateOverlay(quotes, 1000, opts)
+			if res.OptionPremiums*tt.wantSign <= 0 {
+				t.Fatalf("prêmios = %.4f, esperado sinal %+.0f", res.OptionPremiums, tt.wantSign)
+			}
+			// Preço constante: as opções vencem sem valor e só os prêmios mexem na posição
+			if math.Abs(res.FinalValue-(1000+res.OptionPremiums)) > 1e-9 {
+				t.Errorf("valor final = %.4f, esperado %.4f", res.FinalValue, 1000+res.OptionPremiums)
+			}
+			if tt.wantCash {
+				if math.Abs(res.CashBalance-res.OptionPremiums) > 1e-9 || res.TotalAccumulated != 10 {
+					t.Errorf("caixa %.4f e %.4f cotas; esperado %.4f e 10", res.CashBalance, res.TotalAccumulated, res.OptionPremiums)
+				}
+			} else if res.CashBalance != 0 || math.Abs(res.TotalAccumulated-(10+res.OptionPremiums/100)) > 1e-9 {
+				t.Errorf("caixa %.4f e %.4f cotas; esperado prêmios reinvestidos", res.CashBalance, res.TotalAccumulated)
+			}
+			if len(res.Notices) != 0 {
+				t.Errorf("avisos com histórico suficiente: %q", res.Notices)
+			}
+		})
+	}
+}
+
+func TestOverlayAssignment(t *testing.T) {
+	tests := []struct {
+		name      string
+		opts      OverlayOptions
+		closes    []float64
+		wantValue float64
+		wantUnits float64
+		wantCash  float64
+	}{
+		// Sem volatilidade e sem juros os prêmios são zero e só o exercício conta
+		{"call exercida, reinvestida", OverlayOptions{Kind: CoveredCall, Moneyness: 1.05}, []float64{100, 120}, 1050, 8.75, 0},
+		{"call exercida, em caixa", OverlayOptions{Kind: CoveredCall, Moneyness: 1.05, Premiums: PremiumCash}, []float64{100, 120}, 1050, 8.75, 0},
+		{"call não exercida", OverlayOptions{Kind: CoveredCall, Moneyness: 1.05}, []float64{100, 104}, 1040, 10, 0},
+		{"put exercida, reinvestida", OverlayOptions{Kind: ProtectivePut, Moneyness: 0.95}, []float64{100, 80}, 950, 11.875, 0},
+		{"put exercida, em caixa", OverlayOptions{Kind: ProtectivePut, Moneyness: 0.95, Premiums: PremiumCash}, []float64{100, 80}, 950, 10, 150},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := CalculateOverlay(monthlyQuotes(tt.closes...), 1000, tt.opts)
+			if res.OptionPremiums != 0 {
+				t.Fatalf("prêmios = %.4f, esperado 0", res.OptionPremiums)
+			}
+			if math.Abs(res.FinalValue-tt.wantValue) > 1e-9 || math.Abs(res.TotalAccumulated-tt.wantUnits) > 1e-9 || math.Abs(res.CashBalance-tt.wantCash) > 1e-9 {
+				t.Errorf("valor %.4f, cotas %.4f, caixa %.4f; esperado %.4f, %.4f, %.4f",
+					res.FinalValue, res.TotalAccumulated, res.CashBalance, tt.wantValue, tt.wantUnits, tt.wantCash)
+			}
+		})
+	}
+}
+
+func TestOverlayIgnoresFutureVolatility(t *testing.T) {
+	// Sem histórico anterior, a primeira rolagem não pode usar a alta que vem depois
+	res := CalculateOverlay(monthlyQuotes(100, 300), 1000, OverlayOptions{Kind: CoveredCall, Moneyness: 1.05})
+	if res.OptionPremiums != 0 {
+		t.Errorf("prêmios = %.4f, esperado 0 sem volatilidade passada", res.OptionPremiums)
+	}
+	if len(res.Notices) != 1 {
+		t.Errorf("avisos = %q, esperado o de histórico insuficiente", res.Notices)
+	}
+}
@@ -0,0 +1,801 @@
+package calculator
+
+import (
+	"context"
+	"dca-platform/pkg/finance"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Linguagem dos scripts de estratégia: expressões numéricas sem laços, sem acesso a arquivos ou rede.
+//
+//	# Compra o dobro com RSI abaixo de 30 e pula o aporte acima da média de 200 pregões
+//	let base = amount
+//	if price > sma(200) then 0
+//	else if rsi(14) < 30 then 2 * base
+//	else base
+//
+// Um script é uma sequência de "let nome = expressão" seguida da expressão final, que dá o valor a
+// comprar (positivo) ou a vender (negativo) no passo. Instruções podem ser separadas por ";" ou quebra
+// de linha e "#" inicia um comentário. Comparações e and/or/not valem 1 (verdadeiro) ou 0 (falso).
+
+var (
+	errScriptSteps    = errors.New("limite de passos do script excedido")
+	errScriptTimeout  = errors.New("tempo limite do script excedido")
+	errScriptCanceled = errors.New("simulação do script cancelada")
+)
+
+const (
+	scriptMaxSteps  = 1000000  // Operações de uma avaliação (cada nó e cada pregão lido pelas funções)
+	scriptMaxSource = 16 << 10 // Tamanho máximo do código, em bytes
+	scriptMaxDepth  = 100      // Aninhamento máximo de parênteses, if, not e sinais de menos
+)
+
+// scriptVars são as variáveis de cada passo, somente leitura
+var scriptVars = map[string]string{
+	"price":    "Preço de fechamento do passo",
+	"amount":   "Aporte base da estratégia",
+	"units":    "Cotas em carteira",
+	"cash":     "Caixa de vendas ainda não reaplicado",
+	"invested": "Total aportado até o passo",
+	"value":    "Valor da carteira (cotas a preço atual + caixa)",
+	"step":     "Número do passo (0 no primeiro)",
+	"day":      "Pregão na série (0 no primeiro)",
+}
+
+// scriptFunc é uma função embutida: recebe os argumentos avaliados e o estado do passo
+type scriptFunc struct {
+	arity int
+	help  string
+	call  func(env *scriptEnv, args []float64) (float64, error)
+}
+
+// scriptFuncs são as funções embutidas; janelas em pregões terminam no pregão do passo
+var scriptFuncs = map[string]scriptFunc{
+	"sma":    {1, "Média móvel simples de n pregões", func(e *scriptEnv, a []float64) (float64, error) { return e.sma(a[0]) }},
+	"ema":    {1, "Média móvel exponencial de n pregões", func(e *scriptEnv, a []float64) (float64, error) { return e.ema(a[0]) }},
+	"rsi":    {1, "Índice de força relativa (Wilder) de n pregões, de 0 a 100", func(e *scriptEnv, a []float64) (float64, error) { return e.rsi(a[0]) }},
+	"high":   {1, "Máxima dos fechamentos de n pregões", func(e *scriptEnv, a []float64) (float64, error) { return e.extreme(a[0], true) }},
+	"low":    {1, "Mínima dos fechamentos de n pregões", func(e *scriptEnv, a []float64) (float64, error) { return e.extreme(a[0], false) }},
+	"close":  {1, "Fechamento de n pregões atrás (close(0) = price)", func(e *scriptEnv, a []float64) (float64, error) { return e.close(a[0]) }},
+	"change": {1, "Variação % do preço em n pregões", func(e *scriptEnv, a []float64) (float64, error) { return e.change(a[0]) }},
+	"vol":    {1, "Volatilidade histórica anualizada (%) de n pregões", func(e *scriptEnv, a []float64) (float64, error) { return e.vol(a[0]) }},
+	"abs":    {1, "Valor absoluto", func(e *scriptEnv, a []float64) (float64, error) { return math.Abs(a[0]), nil }},
+	"sqrt":   {1, "Raiz quadrada", func(e *scriptEnv, a []float64) (float64, error) { return math.Sqrt(a[0]), nil }},
+	"round":  {1, "Arredondamento", func(e *scriptEnv, a []float64) (float64, error) { return math.Round(a[0]), nil }},
+	"min":    {2, "Menor de dois valores", func(e *scriptEnv, a []float64) (float64, error) { return math.Min(a[0], a[1]), nil }},
+	"max":    {2, "Maior de dois valores", func(e *scriptEnv, a []float64) (float64, error) { return math.Max(a[0], a[1]), nil }},
+}
+
+// Script é um script de estratégia compilado
+type Script struct {
+	source string
+	lets   []scriptLet
+	result scriptNode
+}
+
+type scriptLet struct {
+	name string
+	expr scriptNode
+}
+
+// CompileScript interpreta o código do script, recusando sintaxe inválida, variáveis e funções
+// desconhecidas e chamadas com número errado de argumentos
+func CompileScript(source string) (*Script, error) {
+	if len(source) > scriptMaxSource {
+		return nil, fmt.Errorf("script com %d bytes; o máximo é %d", len(source), scriptMaxSource)
+	}
+	tokens, err := tokenizeScript(source)
+	if err != nil {
+		return nil, err
+	}
+	p := &scriptParser{tokens: tokens, locals: map[string]bool{}}
+	script := &Script{source: source}
+	for {
+		p.skipSeparators()
+		if p.peek().kind == tokEOF {
+			break
+		}
+		if script.result != nil {
+			return nil, p.errorf(p.peek(), "a expressão final deve ser a última instrução")
+		}
+		if p.peek().is(tokIdent, "let") {
+			let, err := p.parseLet()
+			if err != nil {
+				return nil, err
+			}
+			script.lets = append(script.lets, let)
+			continue
+		}
+		node, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		script.result = node
+		if t := p.peek(); t.kind != tokEOF && t.kind != tokSep {
+			return nil, p.errorf(t, "esperado fim da instrução, encontrado %q", t.text)
+		}
+	}
+	if script.result == nil {
+		return nil, errors.New("script sem expressão final com o valor a comprar ou vender")
+	}
+	return script, nil
+}
+
+// Source devolve o código do script
+func (s *Script) Source() string {
+	return s.source
+}
+
+// scriptEnv é o estado visível ao script em um passo
+type scriptEnv struct {
+	quotes   []finance.Quote
+	day      int // Pregão do passo
+	vars     map[string]float64
+	steps    int
+	deadline time.Time
+	ctx      context.Context // Requisição de origem; cancelada, interrompe o script (nil = só o deadline)
+}
+
+// tick conta uma operação e confere os limites de passos e de tempo
+func (e *scriptEnv) tick(n int) error {
+	e.steps += n
+	if e.steps > scriptMaxSteps {
+		return errScriptSteps
+	}
+	if e.steps&1023 < n {
+		return e.expired()
+	}
+	return nil
+}
+
+// expired confere o cancelamento da requisição e o tempo limite
+func (e *scriptEnv) expired() error {
+	if e.ctx != nil && e.ctx.Err() != nil {
+		return errScriptCanceled
+	}
+	if time.Now().After(e.deadline) {
+		return errScriptTimeout
+	}
+	return nil
+}
+
+// eval avalia o script no passo, devolvendo o valor a comprar (positivo) ou vender (negativo)
+func (s *Script) eval(env *scriptEnv) (float64, error) {
+	env.steps = 0
+	if err := env.expired(); err != nil {
+		return 0, err
+	}
+	for _, let := range s.lets {
+		v, err := let.expr.eval(env)
+		if err != nil {
+			return 0, err
+		}
+		env.vars[let.name] = v
+	}
+	v, err := s.result.eval(env)
+	if err != nil {
+		return 0, err
+	}
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return 0, fmt.Errorf("resultado inválido (%v) em %s", v, env.quotes[env.day].Date.Format("2006-01-02"))
+	}
+	return v, nil
+}
+
+// window devolve os até n pregões terminados no pregão do passo
+func (e *scriptEnv) window(n float64) ([]finance.Quote, error) {
+	size := int(n)
+	if size < 1 {
+		return nil, fmt.Errorf("janela inválida: %v", n)
+	}
+	from := e.day + 1 - size
+	if from < 0 {
+		from = 0
+	}
+	if err := e.tick(e.day + 1 - from); err != nil {
+		return nil, err
+	}
+	return e.quotes[from : e.day+1], nil
+}
+
+func (e *scriptEnv) sma(n float64) (float64, error) {
+	w, err := e.window(n)
+	if err != nil {
+		return 0, err
+	}
+	var sum float64
+	for _, q := range w {
+		sum += q.Close
+	}
+	return sum / float64(len(w)), nil
+}
+
+func (e *scriptEnv) ema(n float64) (float64, error) {
+	if n < 1 {
+		return 0, fmt.Errorf("janela inválida: %v", n)
+	}
+	// Histórico de 4n pregões para a média convergir
+	w, err := e.window(n * 4)
+	if err != nil {
+		return 0, err
+	}
+	alpha := 2 / (n + 1)
+	avg := w[0].Close
+	for _, q := range w[1:] {
+		avg += alpha * (q.Close - avg)
+	}
+	return avg, nil
+}
+
+// rsi usa a média de Wilder das altas e quedas, com 4n pregões de aquecimento; sem histórico devolve 50
+func (e *scriptEnv) rsi(n float64) (float64, error) {
+	if n < 1 {
+		return 0, fmt.Errorf("janela inválida: %v", n)
+	}
+	w, err := e.window(n*4 + 1)
+	if err != nil {
+		return 0, err
+	}
+	if len(w) < 2 {
+		return 50, nil
+	}
+	var gain, loss float64
+	for i := 1; i < len(w); i++ {
+		d := w[i].Close - w[i-1].Close
+		up, down := math.Max(d, 0), math.Max(-d, 0)
+		if i <= int(n) {
+			gain += up / n
+			loss += down / n
+			continue
+		}
+		gain = (gain*(n-1) + up) / n
+		loss = (loss*(n-1) + down) / n
+	}
+	if gain+loss == 0 {
+		return 50, nil
+	}
+	return 100 * gain / (gain + loss), nil
+}
+
+func (e *scriptEnv) extreme(n float64, high bool) (float64, error) {
+	w, err := e.window(n)
+	if err != nil {
+		return 0, err
+	}
+	v := w[0].Close
+	for _, q := range w[1:] {
+		if (high && q.Close > v) || (!high && q.Close < v) {
+			v = q.Close
+		}
+	}
+	return v, nil
+}
+
+func (e *scriptEnv) close(n float64) (float64, error) {
+	w, err := e.window(n + 1)
+	if err != nil {
+		return 0, err
+	}
+	return w[0].Close, nil
+}
+
+func (e *scriptEnv) change(n float64) (float64, error) {
+	w, err := e.window(n + 1)
+	if err != nil {
+		return 0, err
+	}
+	if w[0].Close <= 0 {
+		return 0, nil
+	}
+	return (w[len(w)-1].Close/w[0].Close - 1) * 100, nil
+}
+
+func (e *scriptEnv) vol(n float64) (float64, error) {
+	w, err := e.window(n + 1)
+	if err != nil {
+		return 0, err
+	}
+	return HistoricalVolatility(w) * 100, nil
+}
+
+// --- Análise léxica ---
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokNumber
+	tokIdent
+	tokOp
+	tokSep // ";" ou quebra de linha
+)
+
+type scriptToken struct {
+	kind tokenKind
+	text string
+	num  float64
+	line int
+}
+
+func (t scriptToken) is(kind tokenKind, text string) bool {
+	return t.kind == kind && t.text == text
+}
+
+// tokenizeScript separa o código em números, nomes, operadores e separadores
+func tokenizeScript(src string) ([]scriptToken, error) {
+	var tokens []scriptToken
+	line := 1
+	runes := []rune(src)
+	for i := 0; i < len(runes); {
+		c := runes[i]
+		switch {
+		case c == '#':
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+		case c == '\n' || c == ';':
+			tokens = append(tokens, scriptToken{kind: tokSep, text: string(c), line: line})
+			if c == '\n' {
+				line++
+			}
+			i++
+		case unicode.IsSpace(c):
+			i++
+		case unicode.IsDigit(c) || (c == '.' && i+1 < len(runes) && unicode.IsDigit(runes[i+1])):
+			j := i
+			for j < len(runes) && (unicode.IsDigit(runes[j]) || runes[j] == '.') {
+				j++
+			}
+			num, err := strconv.ParseFloat(string(runes[i:j]), 64)
+			if err != nil {
+				return nil, fmt.Errorf("linha %d: número inválido %q", line, string(runes[i:j]))
+			}
+			tokens = append(tokens, scriptToken{kind: tokNumber, text: string(runes[i:j]), num: num, line: line})
+			i = j
+		case unicode.IsLetter(c) || c == '_':
+			j := i
+			for j < len(runes) && (unicode.IsLetter(runes[j]) || unicode.IsDigit(runes[j]) || runes[j] == '_') {
+				j++
+			}
+			tokens = append(tokens, scriptToken{kind: tokIdent, text: strings.ToLower(string(runes[i:j])), line: line})
+			i = j
+		default:
+			op := string(c)
+			if i+1 < len(runes) {
+				switch two := string(runes[i : i+2]); two {
+				case "<=", ">=", "==", "!=":
+					op = two
+				}
+			}
+			switch op {
+			case "+", "-", "*", "/", "%", "<", ">", "=", "(", ")", ",", "<=", ">=", "==", "!=":
+			default:
+				return nil, fmt.Errorf("linha %d: caractere inesperado %q", line, op)
+			}
+			tokens = append(tokens, scriptToken{kind: tokOp, text: op, line: line})
+			i += len([]rune(op))
+		}
+	}
+	return append(tokens, scriptToken{kind: tokEOF, line: line}), nil
+}
+
+// --- Análise sintática ---
+//
+//	script  = { "let" nome "=" expr sep } expr
+//	expr    = "if" expr "then" expr "else" expr | or
+//	or      = and { "or" and }
+//	and     = not { "and" not }
+//	not     = "not" not | cmp
+//	cmp     = soma [ ("<" | "<=" | ">" | ">=" | "==" | "!=") soma ]
+//	soma    = termo { ("+" | "-") termo }
+//	termo   = unário { ("*" | "/" | "%") unário }
+//	unário  = "-" unário | primário
+//	primário = número | nome | nome "(" [ expr { "," expr } ] ")" | "(" expr ")"
+//
+// Quebras de linha dentro de uma expressão são ignoradas quando a expressão ainda não terminou
+// (depois de operador, "(", ",", "if", "then" e "else").
+
+// scriptKeywords não podem ser usadas como nomes de variáveis
+var scriptKeywords = map[string]bool{"let": true, "if": true, "then": true, "else": true, "and": true, "or": true, "not": true}
+
+type scriptParser struct {
+	tokens []scriptToken
+	pos    int
+	locals map[string]bool // Variáveis definidas por let até aqui
+	depth  int             // Aninhamento atual, limitado a scriptMaxDepth para não estourar a pilha
+}
+
+// enter abre um nível de aninhamento; quem chama deve chamar leave ao terminar
+func (p *scriptParser) enter() error {
+	p.depth++
+	if p.depth > scriptMaxDepth {
+		return p.errorf(p.peek(), "expressão aninhada demais (máximo %d níveis)", scriptMaxDepth)
+	}
+	return nil
+}
+
+func (p *scriptParser) leave() {
+	p.depth--
+}
+
+func (p *scriptParser) peek() scriptToken {
+	return p.tokens[p.pos]
+}
+
+func (p *scriptParser) next() scriptToken {
+	t := p.tokens[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *scriptParser) skipSeparators() {
+	for p.peek().kind == tokSep {
+		p.pos++
+	}
+}
+
+// accept consome o token se for o operador ou palavra-chave informado, pulando quebras de linha antes dele
+func (p *scriptParser) accept(kind tokenKind, text string) bool {
+	save := p.pos
+	p.skipSeparators()
+	if p.peek().is(kind, text) {
+		p.pos++
+		return true
+	}
+	p.pos = save
+	return false
+}
+
+func (p *scriptParser) expect(kind tokenKind, text string) error {
+	if !p.accept(kind, text) {
+		p.skipSeparators()
+		return p.errorf(p.peek(), "esperado %q", text)
+	}
+	return nil
+}
+
+func (p *scriptParser) errorf(t scriptToken, format string, args ...interface{}) error {
+	return fmt.Errorf("linha %d: %s", t.line, fmt.Sprintf(format, args...))
+}
+
+func (p *scriptParser) parseLet() (scriptLet, error) {
+	p.next() // let
+	name := p.next()
+	if name.kind != tokIdent || scriptKeywords[name.text] {
+		return scriptLet{}, p.errorf(name, "nome de variável inválido %q", name.text)
+	}
+	if _, ok := scriptVars[name.text]; ok {
+		return scriptLet{}, p.errorf(name, "%s é uma variável do sistema e não pode ser redefinida", name.text)
+	}
+	if _, ok := scriptFuncs[name.text]; ok {
+		return scriptLet{}, p.errorf(name, "%s é uma função embutida", name.text)
+	}
+	if !p.peek().is(tokOp, "=") {
+		return scriptLet{}, p.errorf(p.peek(), "esperado \"=\" depois de let %s", name.text)
+	}
+	p.next()
+	expr, err := p.parseExpr()
+	if err != nil {
+		return scriptLet{}, err
+	}
+	if t := p.peek(); t.kind != tokEOF && t.kind != tokSep {
+		return scriptLet{}, p.errorf(t, "esperado fim da instrução, encontrado %q", t.text)
+	}
+	p.locals[name.text] = true
+	return scriptLet{name: name.text, expr: expr}, nil
+}
+
+func (p *scriptParser) parseExpr() (scriptNode, error) {
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer p.leave()
+	p.skipSeparators()
+	if !p.peek().is(tokIdent, "if") {
+		return p.parseOr()
+	}
+	p.next()
+	cond, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(tokIdent, "then"); err != nil {
+		return nil, err
+	}
+	then, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(tokIdent, "else"); err != nil {
+		return nil, err
+	}
+	otherwise, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	return &ifNode{cond: cond, then: then, otherwise: otherwise}, nil
+}
+
+// parseBinary lê uma sequência associativa à esquerda de operandos ligados pelos operadores informados
+func (p *scriptParser) parseBinary(kind tokenKind, ops []string, operand func() (scriptNode, error)) (scriptNode, error) {
+	left, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t := p.peek()
+		matched := ""
+		for _, op := range ops {
+			if t.is(kind, op) {
+				matched = op
+			}
+		}
+		if matched == "" {
+			return left, nil
+		}
+		p.next()
+		right, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{op: matched, left: left, right: right}
+	}
+}
+
+func (p *scriptParser) parseOr() (scriptNode, error) {
+	return p.parseBinary(tokIdent, []string{"or"}, p.parseAnd)
+}
+
+func (p *scriptParser) parseAnd() (scriptNode, error) {
+	return p.parseBinary(tokIdent, []string{"and"}, p.parseNot)
+}
+
+func (p *scriptParser) parseNot() (scriptNode, error) {
+	p.skipSeparators()
+	if p.peek().is(tokIdent, "not") {
+		p.next()
+		if err := p.enter(); err != nil {
+			return nil, err
+		}
+		defer p.leave()
+		operand, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &notNode{operand: operand}, nil
+	}
+	return p.parseCmp()
+}
+
+func (p *scriptParser) parseCmp() (scriptNode, error) {
+	left, err := p.parseSum()
+	if err != nil {
+		return nil, err
+	}
+	for _, op := range []string{"<", "<=", ">", ">=", "==", "!="} {
+		if p.peek().is(tokOp, op) {
+			p.next()
+			right, err := p.parseSum()
+			if err != nil {
+				return nil, err
+			}
+			return &binaryNode{op: op, left: left, right: right}, nil
+		}
+	}
+	return left, nil
+}
+
+func (p *scriptParser) parseSum() (scriptNode, error) {
+	return p.parseBinary(tokOp, []string{"+", "-"}, p.parseTerm)
+}
+
+func (p *scriptParser) parseTerm() (scriptNode, error) {
+	return p.parseBinary(tokOp, []string{"*", "/", "%"}, p.parseUnary)
+}
+
+func (p *scriptParser) parseUnary() (scriptNode, error) {
+	p.skipSeparators()
+	if p.peek().is(tokOp, "-") {
+		p.next()
+		if err := p.enter(); err != nil {
+			return nil, err
+		}
+		defer p.leave()
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &binaryNode{op: "-", left: numberNode(0), right: operand}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *scriptParser) parsePrimary() (scriptNode, error) {
+	p.skipSeparators()
+	t := p.next()
+	switch {
+	case t.kind == tokNumber:
+		return numberNode(t.num), nil
+	case t.is(tokOp, "("):
+		expr, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(tokOp, ")"); err != nil {
+			return nil, err
+		}
+		return expr, nil
+	case t.is(tokIdent, "if"):
+		// if como operando (ex: 2 * if rsi(14) < 30 then 1 else 0.5)
+		p.pos--
+		return p.parseExpr()
+	case t.kind == tokIdent && !scriptKeywords[t.text]:
+		if p.peek().is(tokOp, "(") {
+			return p.parseCall(t)
+		}
+		if _, ok := scriptVars[t.text]; !ok && !p.locals[t.text] {
+			return nil, p.errorf(t, "variável desconhecida %q", t.text)
+		}
+		return varNode(t.text), nil
+	case t.kind == tokEOF:
+		return nil, p.errorf(t, "fim inesperado do script")
+	}
+	return nil, p.errorf(t, "expressão inesperada %q", t.text)
+}
+
+func (p *scriptParser) parseCall(name scriptToken) (scriptNode, error) {
+	fn, ok := scriptFuncs[name.text]
+	if !ok {
+		return nil, p.errorf(name, "função desconhecida %q", name.text)
+	}
+	p.next() // (
+	var args []scriptNode
+	if !p.accept(tokOp, ")") {
+		for {
+			arg, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+			if p.accept(tokOp, ")") {
+				break
+			}
+			if err := p.expect(tokOp, ","); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if len(args) != fn.arity {
+		return nil, p.errorf(name, "%s espera %d argumento(s), recebeu %d", name.text, fn.arity, len(args))
+	}
+	return &callNode{fn: fn, args: args}, nil
+}
+
+// --- Avaliação ---
+
+type scriptNode interface {
+	eval(env *scriptEnv) (float64, error)
+}
+
+type numberNode float64
+
+func (n numberNode) eval(env *scriptEnv) (float64, error) {
+	return float64(n), env.tick(1)
+}
+
+type varNode string
+
+func (n varNode) eval(env *scriptEnv) (float64, error) {
+	return env.vars[string(n)], env.tick(1)
+}
+
+type notNode struct {
+	operand scriptNode
+}
+
+func (n *notNode) eval(env *scriptEnv) (float64, error) {
+	v, err := n.operand.eval(env)
+	if err != nil {
+		return 0, err
+	}
+	return truth(v == 0), env.tick(1)
+}
+
+type ifNode struct {
+	cond, then, otherwise scriptNode
+}
+
+func (n *ifNode) eval(env *scriptEnv) (float64, error) {
+	cond, err := n.cond.eval(env)
+	if err != nil {
+		return 0, err
+	}
+	if cond != 0 {
+		return n.then.eval(env)
+	}
+	return n.otherwise.eval(env)
+}
+
+type binaryNode struct {
+	op          string
+	left, right scriptNode
+}
+
+func (n *binaryNode) eval(env *scriptEnv) (float64, error) {
+	if err := env.tick(1); err != nil {
+		return 0, err
+	}
+	l, err := n.left.eval(env)
+	if err != nil {
+		return 0, err
+	}
+	// and/or só avaliam o lado direito quando necessário
+	if (n.op == "and" && l == 0) || (n.op == "or" && l != 0) {
+		return truth(l != 0), nil
+	}
+	r, err := n.right.eval(env)
+	if err != nil {
+		return 0, err
+	}
+	switch n.op {
+	case "+":
+		return l + r, nil
+	case "-":
+		return l - r, nil
+	case "*":
+		return l * r, nil
+	case "/":
+		return l / r, nil
+	case "%":
+		return math.Mod(l, r), nil
+	case "<":
+		return truth(l < r), nil
+	case "<=":
+		return truth(l <= r), nil
+	case ">":
+		return truth(l > r), nil
+	case ">=":
+		return truth(l >= r), nil
+	case "==":
+		return truth(l == r), nil
+	case "!=":
+		return truth(l != r), nil
+	}
+	return truth(r != 0), nil // and/or
+}
+
+type callNode struct {
+	fn   scriptFunc
+	args []scriptNode
+}
+
+func (n *callNode) eval(env *scriptEnv) (float64, error) {
+	args := make([]float64, len(n.args))
+	for i, a := range n.args {
+		v, err := a.eval(env)
+		if err != nil {
+			return 0, err
+		}
+		args[i] = v
+	}
+	if err := env.tick(1); err != nil {
+		return 0, err
+	}
+	return n.fn.call(env, args)
+}
+
+// truth converte uma condição em 1 ou 0
+func truth(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,230 @@
+package calculator
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+// evalScript compila e avalia o script no último pregão da série, com price = último fechamento
+func evalScript(t *testing.T, source string, closes ...float64) (float64, error) {
+	t.Helper()
+	script, err := CompileScript(source)
+	if err != nil {
+		t.Fatalf("CompileScript(%q): %v", source, err)
+	}
+	quotes := monthlyQuotes(closes...)
+	env := &scriptEnv{quotes: quotes, day: len(quotes) - 1, vars: map[string]float64{
+		"price": quotes[len(quotes)-1].Close, "amount": 100, "units": 2, "cash": 50,
+		"invested": 300, "value": 2*quotes[len(quotes)-1].Close + 50, "step": 3, "day": float64(len(quotes) - 1),
+	}, deadline: time.Now().Add(time.Second)}
+	return script.eval(env)
+}
+
+func TestScriptEval(t *testing.T) {
+	tests := []struct {
+		source string
+		want   float64
+	}{
+		{"1 + 2 * 3", 7},
+		{"(1 + 2) * 3", 9},
+		{"10 - 4 - 3", 3},
+		{"7 % 4", 3},
+		{"-2 * -3", 6},
+		{"1 < 2 and 2 < 1", 0},
+		{"1 < 2 or 2 < 1", 1},
+		{"not 1 == 2", 1},
+		{"if price > 100 then amount else -amount", -100},
+		{"if 1 then if 0 then 1 else 2 else 3", 2},
+		{"let a = 2\nlet b = a * 3; b + 1", 7},
+		{"amount +\n  cash  # continua na linha seguinte", 150},
+		{"units * price + cash == value", 1},
+		{"sma(3)", 90},
+		{"high(3) - low(3)", 20},
+		{"close(2)", 80},
+		{"change(1)", -10},
+		{"min(amount, cash) + max(1, 2)", 52},
+		{"round(2.6) + abs(-1) + sqrt(16)", 8},
+		{"PRICE", 90},
+	}
+	for _, tt := range tests {
+		got, err := evalScript(t, tt.source, 80, 100, 90)
+		if err != nil {
+			t.Errorf("%q: %v", tt.source, err)
+			continue
+		}
+		if math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("%q = %v, esperado %v", tt.source, got, tt.want)
+		}
+	}
+}
+
+func TestCompileScriptErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   string // Trecho esperado na mensagem de erro
+	}{
+		{"vazio", "", "sem expressão final"},
+		{"só let", "let a = 1", "sem expressão final"},
+		{"variável desconhecida", "preco * 2", "preco"},
+		{"função desconhecida", "media(10)", "media"},
+		{"aridade", "min(1)", "min"},
+		{"redefine variável do sistema", "let price = 1\nprice", "variável do sistema"},
+		{"redefine função", "let sma = 1\nsma", "função embutida"},
+		{"palavra reservada", "let if = 1\n1", "nome de variável inválido"},
+		{"parêntese aberto", "(1 + 2", ")"},
+		{"if sem else", "if 1 then 2", "else"},
+		{"expressão antes do fim", "1\n2", "última instrução"},
+		{"caractere inválido", "1 & 2", "caractere inesperado"},
+		{"número inválido", "1.2.3", "número inválido"},
+		{"aninhamento", strings.Repeat("(", scriptMaxDepth+1) + "1" + strings.Repeat(")", scriptMaxDepth+1), "aninhada demais"},
+		{"aninhamento por sinais", strings.Repeat("-", scriptMaxDepth+1) + "1", "aninhada demais"},
+		{"tamanho", "1" + strings.Repeat(" ", scriptMaxSource), "máximo"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := CompileScript(tt.source)
+			if err == nil {
+				t.Fatalf("CompileScript(%q) sem erro", tt.source)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("erro = %q, esperado conter %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompileScriptDeepNestingWithinLimit(t *testing.T) {
+	n := scriptMaxDepth / 2
+	source := strings.Repeat("(", n) + "1" + strings.Repeat(")", n)
+	if _, err := CompileScript(source); err != nil {
+		t.Fatalf("aninhamento de %d níveis recusado: %v", n, err)
+	}
+}
+
+func TestRunScript(t *testing.T) {
+	quotes := monthlyQuotes(100, 50, 100, 200)
+	tests := []struct {
+		name         string
+		source       string
+		wantInvested float64
+		wantUnits    float64
+		wantCash     float64
+	}{
+		{"aporte fixo", "amount", 400, 1 + 2 + 1 + 0.5, 0},
+		{"dobra na queda", "if price < 100 then 2 * amount else amount", 500, 1 + 4 + 1 + 0.5, 0},
+		{"vende tudo na alta", "if price >= 200 then -value else amount", 300, 0, 800},
+		{"recompra com o caixa", "if step == 0 then amount else if step == 2 then -value else if step == 3 then cash else 0", 100, 0.5, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			script, err := CompileScript(tt.source)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res, err := RunScript(quotes, script, ScriptOptions{Amount: 100, Frequency: Monthly})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if math.Abs(res.TotalInvested-tt.wantInvested) > 1e-9 ||
+				math.Abs(res.TotalAccumulated-tt.wantUnits) > 1e-9 ||
+				math.Abs(res.CashBalance-tt.wantCash) > 1e-9 {
+				t.Errorf("investido %.2f, cotas %.4f, caixa %.2f; esperado %.2f, %.4f, %.2f",
+					res.TotalInvested, res.TotalAccumulated, res.CashBalance, tt.wantInvested, tt.wantUnits, tt.wantCash)
+			}
+		})
+	}
+}
+
+func TestRunScriptLimits(t *testing.T) {
+	closes := make([]float64, 3000)
+	for i := range closes {
+		closes[i] = 100
+	}
+	quotes := monthlyQuotes(closes...)
+	sums := func(calls int) string {
+		return strings.TrimSuffix(strings.Repeat("sma(3000)+", calls), "+")
+	}
+
+	// No último pregão, cada sma(3000) lê 3000 pregões: o limite de passos interrompe a avaliação
+	script, err := CompileScript("if day < 2999 then 0 else " + sums(1000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = RunScript(quotes, script, ScriptOptions{Amount: 100, Frequency: Monthly, Timeout: time.Minute})
+	if err == nil || !strings.Contains(err.Error(), errScriptSteps.Error()) {
+		t.Errorf("erro = %v, esperado limite de passos", err)
+	}
+
+	// Abaixo do limite de passos em cada pregão, mas lento no conjunto: o tempo limite interrompe
+	script, err = CompileScript(sums(300))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = RunScript(quotes, script, ScriptOptions{Amount: 100, Frequency: Monthly, Timeout: 10 * time.Millisecond})
+	if err == nil || !strings.Contains(err.Error(), errScriptTimeout.Error()) {
+		t.Errorf("erro = %v, esperado tempo limite", err)
+	}
+
+	// Divisão por zero no resultado é recusada
+	script, _ = CompileScript("amount / 0")
+	if _, err := RunScript(quotes, script, ScriptOptions{Amount: 100, Frequency: Monthly}); err == nil {
+		t.Error("resultado infinito aceito")
+	}
+}
+
+func TestScriptStrategyReturnsErrors(t *testing.T) {
+	s := &ScriptStrategy{name: "script", description: "Script próprio"}
+	p, err := ValidateParams(s.Params(), map[string]string{"script": "amount / 0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Run(Market{Quotes: monthlyQuotes(100, 110)}, p); err == nil {
+		t.Error("falha do script não devolvida como erro")
+	}
+}
+
+func TestRunScriptStopsWhenCanceled(t *testing.T) {
+	closes := make([]float64, 3000)
+	for i := range closes {
+		closes[i] = 100
+	}
+	quotes := monthlyQuotes(closes...)
+	script, err := CompileScript(strings.TrimSuffix(strings.Repeat("sma(3000)+", 300), "+"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Requisição já cancelada: nem o primeiro passo roda
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = RunScript(quotes, script, ScriptOptions{Amount: 100, Frequency: Daily, Timeout: time.Minute, Context: ctx})
+	if err == nil || !strings.Contains(err.Error(), errScriptCanceled.Error()) {
+		t.Errorf("erro = %v, esperado cancelamento", err)
+	}
+
+	// Cancelada no meio: para bem antes do tempo limite, também pela estratégia do registro
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	s := &ScriptStrategy{name: "script", description: "Script próprio"}
+	p, err := ValidateParams(s.Params(), map[string]string{"script": script.Source(), "frequency": "daily", "timeout_ms": "5000"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	_, err = s.Run(Market{Quotes: quotes, Context: ctx}, p)
+	if err == nil || !strings.Contains(err.Error(), errScriptCanceled.Error()) {
+		t.Errorf("erro = %v, esperado cancelamento", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("parou em %v, esperado logo após o cancelamento", elapsed)
+	}
+
+	// Acima do máximo do esquema
+	if _, err := ValidateParams(s.Params(), map[string]string{"script": "amount", "timeout_ms": "10000"}); err == nil {
+		t.Error("timeout_ms acima de MaxScriptTimeout aceito")
+	}
+}
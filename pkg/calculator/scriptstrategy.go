@@ -0,0 +1,210 @@
+package calculator
+
+import (
+	"context"
+	"dca-platform/pkg/finance"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultScriptTimeout é o tempo máximo de uma simulação por script quando não informado
+const DefaultScriptTimeout = 2 * time.Second
+
+// MaxScriptTimeout é o maior timeout_ms aceito: o script ocupa uma CPU do servidor enquanto
+// a requisição espera, e uma simulação pode rodar vários scripts
+const MaxScriptTimeout = 5 * time.Second
+
+// ScriptOptions configura a execução de um script de estratégia
+type ScriptOptions struct {
+	Amount    float64       // Aporte base, visível ao script como amount
+	Frequency Frequency     // Datas em que o script é avaliado (como os aportes do DCA)
+	Timeout   time.Duration // Tempo máximo da simulação inteira (0 = DefaultScriptTimeout)
+
+	// Context interrompe a simulação quando cancelado, como quando o cliente HTTP desiste (nil = só o Timeout)
+	Context context.Context
+}
+
+// RunScript avalia o script em cada data da frequência e executa a ordem devolvida: valores positivos
+// compram cotas, usando primeiro o caixa de vendas anteriores e depois dinheiro novo (contado como aporte);
+// valores negativos vendem cotas até o total em carteira e o dinheiro fica em caixa.
+func RunScript(quotes []finance.Quote, script *Script, opts ScriptOptions) (StrategyResult, error) {
+	if len(quotes) == 0 {
+		return StrategyResult{}, fmt.Errorf("sem cotações")
+	}
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = DefaultScriptTimeout
+	}
+
+	env := &scriptEnv{quotes: quotes, vars: make(map[string]float64), deadline: time.Now().Add(timeout), ctx: opts.Context}
+	var units, cash, invested float64
+	lastStep := time.Time{}
+	step := 0
+	for i, q := range quotes {
+		if q.Close <= 0 || !isPurchaseDate(opts.Frequency, lastStep, q.Date) {
+			continue
+		}
+		lastStep = q.Date
+
+		env.day = i
+		env.vars["price"] = q.Close
+		env.vars["amount"] = opts.Amount
+		env.vars["units"] = units
+		env.vars["cash"] = cash
+		env.vars["invested"] = invested
+		env.vars["value"] = units*q.Close + cash
+		env.vars["step"] = float64(step)
+		env.vars["day"] = float64(i)
+		order, err := script.eval(env)
+		if err != nil {
+			return StrategyResult{}, fmt.Errorf("%s: %v", q.Date.Format("2006-01-02"), err)
+		}
+		step++
+
+		if order > 0 {
+			fromCash := math.Min(cash, order)
+			cash -= fromCash
+			invested += order - fromCash
+			units += order / q.Close
+		} else if order < 0 {
+			sold := math.Min(-order, units*q.Close)
+			units -= sold / q.Close
+			cash += sold
+		}
+	}
+
+	finalValue := units*quotes[len(quotes)-1].Close + cash
+	ret := 0.0
+	if invested > 0 {
+		ret = (finalValue - invested) / invested * 100
+	}
+	return StrategyResult{
+		TotalInvested:    invested,
+		FinalValue:       finalValue,
+		ReturnPercent:    ret,
+		TotalAccumulated: units,
+		CashBalance:      cash,
+	}, nil
+}
+
+// ScriptStrategy é uma estratégia definida por script, sem recompilar a aplicação.
+// Sem script fixo (a estratégia "script"), o código vem no parâmetro script de cada execução.
+type ScriptStrategy struct {
+	name        string
+	description string
+	script      *Script
+}
+
+// NewScriptStrategy compila um script fixo e o expõe como estratégia
+func NewScriptStrategy(name, description, source string) (*ScriptStrategy, error) {
+	script, err := CompileScript(source)
+	if err != nil {
+		return nil, err
+	}
+	return &ScriptStrategy{name: name, description: description, script: script}, nil
+}
+
+func (s *ScriptStrategy) Name() string        { return s.name }
+func (s *ScriptStrategy) Description() string { return s.description }
+
+func (s *ScriptStrategy) Params() []Param {
+	params := []Param{
+		{Name: "amount", Label: "Aporte Base", Type: ParamNumber, Default: "100", Min: Bound(0), Help: "amount no script"},
+		{Name: "frequency", Label: "Frequência", Type: ParamChoice, Default: string(Monthly), Choices: []string{string(Daily), string(Weekly), string(Monthly)}},
+		{Name: "timeout_ms", Label: "Tempo Limite (ms)", Type: ParamInteger, Default: fmt.Sprint(DefaultScriptTimeout.Milliseconds()), Min: Bound(10), Max: Bound(float64(MaxScriptTimeout.Milliseconds()))},
+	}
+	if s.script == nil {
+		params = append([]Param{{Name: "script", Label: "Script", Type: ParamString, Required: true, Help: scriptHelp()}}, params...)
+	}
+	return params
+}
+
+// Check compila o script informado no parâmetro, para recusar erros antes de buscar as cotações
+func (s *ScriptStrategy) Check(p Params) error {
+	_, err := s.compiled(p)
+	return err
+}
+
+// Run executa o script; erros de execução, de tempo ou de limite de passos interrompem a simulação
+func (s *ScriptStrategy) Run(m Market, p Params) (StrategyResult, error) {
+	script, err := s.compiled(p)
+	if err != nil {
+		return StrategyResult{}, err
+	}
+	res, err := RunScript(m.Quotes, script, ScriptOptions{
+		Amount:    p.Float("amount"),
+		Frequency: Frequency(p.String("frequency")),
+		Timeout:   time.Duration(p.Int("timeout_ms")) * time.Millisecond,
+		Context:   m.Context,
+	})
+	if err != nil {
+		return StrategyResult{}, fmt.Errorf("%s: %v", s.description, err)
+	}
+	res.StrategyName = fmt.Sprintf("%s (%s)", s.description, p.String("frequency"))
+	return res, nil
+}
+
+func (s *ScriptStrategy) compiled(p Params) (*Script, error) {
+	if s.script != nil {
+		return s.script, nil
+	}
+	return CompileScript(p.String("script"))
+}
+
+// scriptHelp resume as variáveis e funções disponíveis aos scripts
+func scriptHelp() string {
+	var vars, funcs []string
+	for name := range scriptVars {
+		vars = append(vars, name)
+	}
+	for name, fn := range scriptFuncs {
+		args := "n"
+		if fn.arity == 2 {
+			args = "a, b"
+		}
+		funcs = append(funcs, fmt.Sprintf("%s(%s)", name, args))
+	}
+	sort.Strings(vars)
+	sort.Strings(funcs)
+	return fmt.Sprintf("valor a comprar (+) ou vender (-); variáveis: %s; funções: %s",
+		strings.Join(vars, ", "), strings.Join(funcs, ", "))
+}
+
+// RegisterScripts registra como estratégia cada arquivo <nome>.script do diretório. A primeira
+// linha, se for um comentário, vira a descrição. Scripts inválidos são ignorados e informados no erro.
+func RegisterScripts(r *Registry, dir string) error {
+	files, err := filepath.Glob(filepath.Join(dir, "*.script"))
+	if err != nil {
+		return err
+	}
+	var problems []string
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			problems = append(problems, err.Error())
+			continue
+		}
+		source := string(data)
+		name := strings.TrimSuffix(filepath.Base(file), ".script")
+		description := "Script " + name
+		if first := strings.TrimSpace(strings.SplitN(source, "\n", 2)[0]); strings.HasPrefix(first, "#") {
+			description = strings.TrimSpace(strings.TrimPrefix(first, "#"))
+		}
+		s, err := NewScriptStrategy(name, description, source)
+		if err == nil {
+			err = r.Register(s)
+		}
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", filepath.Base(file), err))
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%s", strings.Join(problems, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,330 @@
+package calculator
+
+import (
+	"context"
+	"dca-platform/pkg/finance"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Strategy é uma estratégia de investimento executada sobre o histórico de um ativo.
+// Estratégias registradas aparecem automaticamente no formulário, na API e na CLI.
+type Strategy interface {
+	Name() string        // Identificador usado no formulário, na API e na CLI (ex: "dca")
+	Description() string // Nome exibido
+	Params() []Param     // Parâmetros aceitos, validados antes de Run
+	Run(market Market, params Params) (StrategyResult, error)
+}
+
+// Market é o que uma estratégia recebe do ativo: o histórico, os proventos e o acesso a outras
+// séries do mesmo período, como o câmbio dos aportes ou o ativo alternativo de um COE
+type Market struct {
+	Symbol    string
+	Currency  string // Moeda das cotações
+	Quotes    []finance.Quote
+	Dividends []finance.Dividend // Na moeda das cotações
+	Country   string             // País do emissor, para a retenção sobre proventos (ver WithholdingFor)
+
+	// Fetch busca outro ativo no período na moeda pedida ("" = original); FX busca o câmbio
+	// em unidades de to por unidade de from. nil: a estratégia não tem acesso a outras séries.
+	Fetch func(symbol, currency string) ([]finance.Quote, error)
+	FX    func(from, to string) ([]finance.Quote, error)
+
+	// Lookback busca os pregões do ativo no ano anterior à janela, para estimativas que não podem
+	// usar dados futuros (ex: a volatilidade na emissão de um COE). nil: sem histórico anterior.
+	Lookback func() ([]finance.Quote, error)
+
+	// Context é o da requisição de origem: estratégias demoradas (scripts) param quando ele é cancelado.
+	// nil: sem cancelamento além dos limites da própria estratégia.
+	Context context.Context
+}
+
+// lookbackDays são os dias corridos antes da janela buscados por Lookback: um ano de pregões e folga para feriados
+const lookbackDays = 380
+
+// ClientMarket monta o Market de uma série do cliente, buscando outros ativos e câmbios no mesmo
+// cliente e período. O país do emissor é deduzido da moeda original da série.
+func ClientMarket(ctx context.Context, c *finance.Client, series finance.Series, startDate, endDate time.Time) Market {
+	return Market{
+		Symbol:    series.Symbol,
+		Currency:  series.Currency,
+		Quotes:    series.Quotes,
+		Dividends: series.Dividends,
+		Country:   CountryForCurrency(series.OriginalCurrency()),
+		Context:   ctx,
+		Fetch: func(symbol, currency string) ([]finance.Quote, error) {
+			s, err := c.GetHistoricalDataIn(ctx, symbol, startDate, endDate, currency)
+			return s.Quotes, err
+		},
+		FX: func(from, to string) ([]finance.Quote, error) {
+			fx, err := c.GetFXRates(ctx, from, to, startDate, endDate)
+			return fx.Quotes, err
+		},
+		Lookback: func() ([]finance.Quote, error) {
+			s, err := c.GetHistoricalDataIn(ctx, series.Symbol, startDate.AddDate(0, 0, -lookbackDays), startDate.AddDate(0, 0, -1), series.Currency)
+			return s.Quotes, err
+		},
+	}
+}
+
+// history devolve os pregões anteriores à janela, ou nil se o Market não os oferece ou a busca falhou
+func (m Market) history() []finance.Quote {
+	if m.Lookback == nil {
+		return nil
+	}
+	quotes, err := m.Lookback()
+	if err != nil {
+		return nil
+	}
+	return quotes
+}
+
+// fetch busca outro ativo pelo Market, com erro se ele não der acesso a outras séries
+func (m Market) fetch(symbol, currency string) ([]finance.Quote, error) {
+	if m.Fetch == nil {
+		return nil, fmt.Errorf("sem acesso à série de %s", symbol)
+	}
+	return m.Fetch(symbol, currency)
+}
+
+// rates busca o câmbio pelo Market, com erro se ele não der acesso a câmbios
+func (m Market) rates(from, to string) ([]finance.Quote, error) {
+	if m.FX == nil {
+		return nil, fmt.Errorf("sem acesso ao câmbio %s/%s", from, to)
+	}
+	rates, err := m.FX(from, to)
+	if err == nil && len(rates) == 0 {
+		err = fmt.Errorf("sem cotações do câmbio %s/%s", from, to)
+	}
+	return rates, err
+}
+
+// Checker é implementada por estratégias com validações além do esquema (ex: compilar um script)
+type Checker interface {
+	Check(params Params) error
+}
+
+// ParamType é o tipo de um parâmetro de estratégia
+type ParamType string
+
+const (
+	ParamNumber  ParamType = "number"
+	ParamInteger ParamType = "integer"
+	ParamBool    ParamType = "bool"
+	ParamChoice  ParamType = "choice"
+	ParamString  ParamType = "string"
+)
+
+// Param descreve um parâmetro de estratégia
+type Param struct {
+	Name     string    `json:"name"`
+	Label    string    `json:"label"`
+	Type     ParamType `json:"type"`
+	Default  string    `json:"default,omitempty"` // Valor usado quando o parâmetro não é informado
+	Required bool      `json:"required,omitempty"`
+	Min      *float64  `json:"min,omitempty"` // Limites de number/integer
+	Max      *float64  `json:"max,omitempty"`
+	Choices  []string  `json:"choices,omitempty"` // Valores aceitos de choice
+	Help     string    `json:"help,omitempty"`
+}
+
+// Bound devolve o ponteiro de um limite de Param
+func Bound(v float64) *float64 {
+	return &v
+}
+
+// Params são os parâmetros de uma execução, já validados contra o esquema da estratégia
+type Params map[string]string
+
+// Float devolve um parâmetro numérico (0 se ausente)
+func (p Params) Float(name string) float64 {
+	v, _ := strconv.ParseFloat(p[name], 64)
+	return v
+}
+
+// Int devolve um parâmetro inteiro (0 se ausente)
+func (p Params) Int(name string) int {
+	v, _ := strconv.Atoi(p[name])
+	return v
+}
+
+// Bool devolve um parâmetro booleano (false se ausente)
+func (p Params) Bool(name string) bool {
+	v, _ := strconv.ParseBool(p[name])
+	return v
+}
+
+// String devolve um parâmetro de texto ou escolha
+func (p Params) String(name string) string {
+	return p[name]
+}
+
+// ValidateParams confere os valores informados contra o esquema, preenche os padrões
+// e recusa parâmetros desconhecidos
+func ValidateParams(schema []Param, values map[string]string) (Params, error) {
+	known := make(map[string]bool, len(schema))
+	params := make(Params, len(schema))
+	for _, p := range schema {
+		known[p.Name] = true
+		v := strings.TrimSpace(values[p.Name])
+		if v == "" {
+			v = p.Default
+		}
+		if v == "" {
+			if p.Required {
+				return nil, fmt.Errorf("parâmetro obrigatório: %s", p.Name)
+			}
+			continue
+		}
+		if err := p.check(v); err != nil {
+			return nil, err
+		}
+		params[p.Name] = v
+	}
+	for name := range values {
+		if !known[name] {
+			return nil, fmt.Errorf("parâmetro desconhecido: %s", name)
+		}
+	}
+	return params, nil
+}
+
+// check valida um valor contra o tipo e os limites do parâmetro
+func (p Param) check(v string) error {
+	switch p.Type {
+	case ParamNumber, ParamInteger:
+		var n float64
+		var err error
+		if p.Type == ParamInteger {
+			var i int
+			i, err = strconv.Atoi(v)
+			n = float64(i)
+		} else {
+			n, err = strconv.ParseFloat(v, 64)
+		}
+		if err != nil {
+			return fmt.Errorf("%s: valor inválido %q", p.Name, v)
+		}
+		if p.Min != nil && n < *p.Min {
+			return fmt.Errorf("%s: mínimo %g", p.Name, *p.Min)
+		}
+		if p.Max != nil && n > *p.Max {
+			return fmt.Errorf("%s: máximo %g", p.Name, *p.Max)
+		}
+	case ParamBool:
+		if _, err := strconv.ParseBool(v); err != nil {
+			return fmt.Errorf("%s: use true ou false", p.Name)
+		}
+	case ParamChoice:
+		for _, c := range p.Choices {
+			if v == c {
+				return nil
+			}
+		}
+		return fmt.Errorf("%s: use um de %s", p.Name, strings.Join(p.Choices, ", "))
+	}
+	return nil
+}
+
+// Registry guarda as estratégias disponíveis pelo nome
+type Registry struct {
+	mu         sync.RWMutex
+	strategies map[string]Strategy
+}
+
+// NewRegistry cria um registro vazio
+func NewRegistry() *Registry {
+	return &Registry{strategies: make(map[string]Strategy)}
+}
+
+// Register adiciona uma estratégia; nomes repetidos são recusados
+func (r *Registry) Register(s Strategy) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	name := strings.ToLower(s.Name())
+	if _, ok := r.strategies[name]; ok {
+		return fmt.Errorf("estratégia já registrada: %s", name)
+	}
+	r.strategies[name] = s
+	return nil
+}
+
+// Lookup procura uma estratégia pelo nome (sem diferenciar maiúsculas)
+func (r *Registry) Lookup(name string) (Strategy, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	s, ok := r.strategies[strings.ToLower(name)]
+	return s, ok
+}
+
+// Strategies devolve as estratégias registradas em ordem de nome
+func (r *Registry) Strategies() []Strategy {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	list := make([]Strategy, 0, len(r.strategies))
+	for _, s := range r.strategies {
+		list = append(list, s)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name() < list[j].Name() })
+	return list
+}
+
+// StrategyInfo descreve uma estratégia registrada para o formulário, a API e a CLI
+type StrategyInfo struct {
+	Name        string  `json:"name"`
+	Description string  `json:"description"`
+	Params      []Param `json:"params"`
+}
+
+// Describe devolve o nome, a descrição e o esquema de parâmetros de cada estratégia
+func (r *Registry) Describe() []StrategyInfo {
+	var infos []StrategyInfo
+	for _, s := range r.Strategies() {
+		infos = append(infos, StrategyInfo{Name: s.Name(), Description: s.Description(), Params: s.Params()})
+	}
+	return infos
+}
+
+// Validate confere os parâmetros contra o esquema e, se a estratégia for um Checker, contra as regras próprias dela
+func (r *Registry) Validate(name string, values map[string]string) (Strategy, Params, error) {
+	s, ok := r.Lookup(name)
+	if !ok {
+		return nil, nil, fmt.Errorf("estratégia desconhecida: %s", name)
+	}
+	params, err := ValidateParams(s.Params(), values)
+	if err != nil {
+		return nil, nil, err
+	}
+	if c, ok := s.(Checker); ok {
+		if err := c.Check(params); err != nil {
+			return nil, nil, err
+		}
+	}
+	return s, params, nil
+}
+
+// Run valida os parâmetros e executa a estratégia sobre o histórico do ativo
+func (r *Registry) Run(name string, market Market, values map[string]string) (StrategyResult, error) {
+	s, params, err := r.Validate(name, values)
+	if err != nil {
+		return StrategyResult{}, err
+	}
+	if len(market.Quotes) == 0 {
+		return StrategyResult{}, fmt.Errorf("sem cotações para a estratégia %s", name)
+	}
+	return s.Run(market, params)
+}
+
+// DefaultRegistry é o registro padrão, com as estratégias embutidas do calculador
+var DefaultRegistry = NewRegistry()
+
+// Register adiciona uma estratégia ao registro padrão. Feito em init: nome repetido entra em pânico.
+func Register(s Strategy) {
+	if err := DefaultRegistry.Register(s); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,122 @@
+// Package catalog carrega o catálogo de ativos oferecidos no formulário a partir de um arquivo JSON.
+package catalog
+
+import (
+	"dca-platform/pkg/calculator"
+	"encoding/json"
+	"strings"
+	"sync"
+)
+
+// Asset descreve um ativo do catálogo
+type Asset struct {
+	Symbol   string `json:"symbol"`
+	Name     string `json:"name"`
+	Category string `json:"category"` // Agrupamento exibido no formulário (Cripto, EUA, Brasil RF...)
+
+	Currency     string  `json:"currency,omitempty"`      // Moeda de cotação (USD, BRL...)
+	AssetClass   string  `json:"asset_class,omitempty"`   // equity, etf, crypto, commodity, fixed_income, index
+	Exchange     string  `json:"exchange,omitempty"`      // Bolsa de negociação (NASDAQ, B3...)
+	Country      string  `json:"country,omitempty"`       // País do emissor (US, BR...): define a retenção sobre proventos
+	TaxCategory  string  `json:"tax_category,omitempty"`  // Regime de IR no Brasil (acoes_br, exterior, cripto, renda_fixa...)
+	ExpenseRatio float64 `json:"expense_ratio,omitempty"` // Taxa de administração ao ano de ETFs/fundos (já embutida na cota)
+
+	CostModel *calculator.TradeCosts `json:"cost_model,omitempty"` // Custos padrão de cada compra
+
+	Issuer string `json:"issuer,omitempty"` // Emissor de produtos de renda fixa (ver config/issuers.json)
+	FGC    bool   `json:"fgc,omitempty"`    // Coberto pelo FGC (CDB, LCI, LCA, poupança)
+
+	Hidden bool `json:"hidden,omitempty"` // Fora da lista do formulário (ex: proxies de COE)
+}
+
+// ExpensePercent devolve a taxa de administração em % ao ano, para exibição
+func (a Asset) ExpensePercent() float64 {
+	return a.ExpenseRatio * 100
+}
+
+// Catalog é o catálogo lido de um arquivo JSON, relido quando muda, sem reiniciar o servidor.
+type Catalog struct {
+	mu     sync.Mutex
+	file   watchedFile
+	assets []Asset
+}
+
+// New cria o catálogo a partir do arquivo informado
+func New(path string) *Catalog {
+	return &Catalog{file: watchedFile{path: path}}
+}
+
+func (c *Catalog) refresh() {
+	c.file.refresh(func(data []byte) error {
+		var assets []Asset
+		if err := json.Unmarshal(data, &assets); err != nil {
+			return err
+		}
+		c.assets = assets
+		return nil
+	})
+}
+
+// All devolve todos os ativos do catálogo, inclusive os ocultos
+func (c *Catalog) All() []Asset {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.refresh()
+	return c.assets
+}
+
+// Assets devolve os ativos exibidos no formulário
+func (c *Catalog) Assets() []Asset {
+	var visible []Asset
+	for _, a := range c.All() {
+		if !a.Hidden {
+			visible = append(visible, a)
+		}
+	}
+	return visible
+}
+
+// Err devolve o erro da última leitura do arquivo, se houver
+func (c *Catalog) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.refresh()
+	return c.file.err
+}
+
+// Lookup procura o ativo pelo símbolo (sem diferenciar maiúsculas)
+func (c *Catalog) Lookup(symbol string) (Asset, bool) {
+	for _, a := range c.All() {
+		if strings.EqualFold(a.Symbol, symbol) {
+			return a, true
+		}
+	}
+	return Asset{}, false
+}
+
+// Search devolve os ativos cujo símbolo ou nome contém o texto buscado.
+// Símbolos que começam pelo texto vêm primeiro.
+func (c *Catalog) Search(query string) []Asset {
+	q := strings.ToUpper(strings.TrimSpace(query))
+	if q == "" {
+		return nil
+	}
+	var prefix, other []Asset
+	for _, a := range c.All() {
+		switch {
+		case strings.HasPrefix(strings.ToUpper(a.Symbol), q):
+			prefix = append(prefix, a)
+		case strings.Contains(strings.ToUpper(a.Symbol), q), strings.Contains(strings.ToUpper(a.Name), q):
+			other = append(other, a)
+		}
+	}
+	return append(prefix, other...)
+}
+
+// Name devolve o nome do ativo ou o próprio símbolo se não estiver no catálogo
+func (c *Catalog) Name(symbol string) string {
+	if a, ok := c.Lookup(symbol); ok {
+		return a.Name
+	}
+	return symbol
+}
@@ -0,0 +1,39 @@
+package catalog
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCatalogThrottlesRefresh(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "assets.json")
+	write := func(content string, mtime time.Time) {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mtime := time.Now().Add(-time.Hour)
+	write(`[{"symbol": "AAA", "name": "Primeiro"}]`, mtime)
+
+	c := New(path)
+	if got := c.Name("AAA"); got != "Primeiro" {
+		t.Fatalf("Name = %q, esperado Primeiro", got)
+	}
+
+	// Dentro do intervalo, a alteração ainda não é vista: o disco não é consultado a cada chamada
+	write(`[{"symbol": "AAA", "name": "Segundo"}]`, mtime.Add(time.Minute))
+	if got := c.Name("AAA"); got != "Primeiro" {
+		t.Errorf("logo após a alteração: Name = %q, esperado Primeiro", got)
+	}
+
+	// Passado o intervalo, a nova versão é lida
+	c.file.checked = c.file.checked.Add(-refreshInterval)
+	if got := c.Name("AAA"); got != "Segundo" {
+		t.Errorf("após o intervalo: Name = %q, esperado Segundo", got)
+	}
+}
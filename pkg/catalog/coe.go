@@ -0,0 +1,216 @@
+package catalog
+
+import (
+	"dca-platform/pkg/calculator"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// COEUnderlying é um ativo objeto de COE e o ticker usado para simulá-lo
+type COEUnderlying struct {
+	ID    string `json:"id"`    // Nome usado no formulário e na API (ex: BIG_TECHS)
+	Name  string `json:"name"`  // Nome exibido
+	Proxy string `json:"proxy"` // Ticker com o histórico do ativo objeto (ex: NASD11.SA)
+}
+
+// COETemplate é um COE pré-definido, com os parâmetros típicos dos produtos oferecidos pelos bancos.
+// Todos os níveis, retornos e cupons estão em % (130 = 130% do preço inicial, 5 = 5%).
+type COETemplate struct {
+	ID            string  `json:"id"`
+	Name          string  `json:"name"`
+	Underlying    string  `json:"underlying"`    // ID de um ativo objeto de underlyings
+	TermMonths    int     `json:"term_months"`   // Prazo do produto
+	Protected     bool    `json:"protected"`     // Capital protegido no vencimento
+	Participation float64 `json:"participation"` // % de participação na alta
+	Cap           float64 `json:"cap"`           // % máximo de retorno (0 = sem teto)
+	Issuer        string  `json:"issuer"`        // Banco emissor
+
+	Payoff        calculator.COEPayoff `json:"payoff,omitempty"`         // "" ponta a ponta, "asian" ou "digital"
+	Strike        float64              `json:"strike,omitempty"`         // Digital: % do preço inicial (padrão 100)
+	DigitalReturn float64              `json:"digital_return,omitempty"` // Digital: % pago acima do strike
+
+	Barrier  *COEBarrierTemplate  `json:"barrier,omitempty"`  // Barreira knock-in/knock-out, se houver
+	Autocall *COEAutocallTemplate `json:"autocall,omitempty"` // Resgate antecipado automático, se houver
+	Coupon   *COECouponTemplate   `json:"coupon,omitempty"`   // Cupons periódicos, se houver
+}
+
+// COEBarrierTemplate é a barreira de um modelo (ver calculator.COEBarrier)
+type COEBarrierTemplate struct {
+	Kind              calculator.BarrierKind        `json:"kind"`
+	Level             float64                       `json:"level"`            // % do preço inicial
+	Rebate            float64                       `json:"rebate,omitempty"` // % pago no knock-out
+	Observation       calculator.BarrierObservation `json:"observation,omitempty"`
+	ObservationMonths int                           `json:"observation_months,omitempty"`
+}
+
+// COEAutocallTemplate é o autocall de um modelo (ver calculator.COEAutocall)
+type COEAutocallTemplate struct {
+	Trigger           float64 `json:"trigger"` // % do preço inicial
+	Coupon            float64 `json:"coupon"`  // % por período de observação
+	ObservationMonths int     `json:"observation_months,omitempty"`
+}
+
+// COECouponTemplate são os cupons de um modelo (ver calculator.COECoupon)
+type COECouponTemplate struct {
+	Rate              float64 `json:"rate"` // % por período
+	ObservationMonths int     `json:"observation_months,omitempty"`
+	Barrier           float64 `json:"barrier,omitempty"` // % do preço inicial; 0 = cupom fixo
+	Memory            bool    `json:"memory,omitempty"`
+}
+
+// validate recusa valores fora da faixa, como frações (1.3) onde se espera % (130), prazos negativos
+// e ativos objeto fora de underlyings
+func (t COETemplate) validate(underlyings []COEUnderlying) error {
+	level := func(name string, v float64) error {
+		if v < 10 || v > 1000 {
+			return fmt.Errorf("modelo %s: %s %v fora da faixa de 10%% a 1000%% do preço inicial", t.ID, name, v)
+		}
+		return nil
+	}
+	rate := func(name string, v float64) error {
+		if v < 0 || v > 100 {
+			return fmt.Errorf("modelo %s: %s %v fora da faixa de 0%% a 100%%", t.ID, name, v)
+		}
+		return nil
+	}
+	if t.Underlying == "" {
+		return fmt.Errorf("modelo %s: sem ativo objeto", t.ID)
+	}
+	known := false
+	for _, u := range underlyings {
+		known = known || strings.EqualFold(u.ID, t.Underlying)
+	}
+	if !known {
+		return fmt.Errorf("modelo %s: ativo objeto %q não cadastrado em underlyings", t.ID, t.Underlying)
+	}
+	if t.TermMonths < 0 {
+		return fmt.Errorf("modelo %s: prazo negativo (%d meses)", t.ID, t.TermMonths)
+	}
+	if t.Participation < 0 || t.Cap < 0 {
+		return fmt.Errorf("modelo %s: participação e teto não podem ser negativos", t.ID)
+	}
+	if t.Payoff == calculator.PayoffDigital && t.Strike != 0 {
+		if err := level("strike", t.Strike); err != nil {
+			return err
+		}
+	}
+	if b := t.Barrier; b != nil {
+		if b.Kind != calculator.KnockOutUp && b.Kind != calculator.KnockInDown {
+			return fmt.Errorf("modelo %s: tipo de barreira desconhecido %q", t.ID, b.Kind)
+		}
+		if b.Observation != calculator.ObserveContinuous && b.Observation != calculator.ObserveDiscrete {
+			return fmt.Errorf("modelo %s: observação da barreira desconhecida %q", t.ID, b.Observation)
+		}
+		if err := level("barreira", b.Level); err != nil {
+			return err
+		}
+		if err := rate("rebate", b.Rebate); err != nil {
+			return err
+		}
+	}
+	if a := t.Autocall; a != nil {
+		if err := level("gatilho do autocall", a.Trigger); err != nil {
+			return err
+		}
+		if err := rate("cupom do autocall", a.Coupon); err != nil {
+			return err
+		}
+	}
+	if c := t.Coupon; c != nil {
+		if err := rate("cupom", c.Rate); err != nil {
+			return err
+		}
+		if c.Barrier != 0 {
+			if err := level("barreira do cupom", c.Barrier); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// coeProducts é o formato do arquivo de COEs
+type coeProducts struct {
+	Underlyings []COEUnderlying `json:"underlyings"`
+	Templates   []COETemplate   `json:"templates"`
+}
+
+// COECatalog é o catálogo de ativos objeto e modelos de COE, relido quando o arquivo muda
+type COECatalog struct {
+	mu       sync.Mutex
+	file     watchedFile
+	products coeProducts
+}
+
+// NewCOECatalog cria o catálogo de COEs a partir do arquivo informado
+func NewCOECatalog(path string) *COECatalog {
+	return &COECatalog{file: watchedFile{path: path}}
+}
+
+func (c *COECatalog) load() coeProducts {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.file.refresh(func(data []byte) error {
+		var products coeProducts
+		if err := json.Unmarshal(data, &products); err != nil {
+			return err
+		}
+		for _, t := range products.Templates {
+			if err := t.validate(products.Underlyings); err != nil {
+				return err
+			}
+		}
+		c.products = products
+		return nil
+	})
+	return c.products
+}
+
+// Err devolve o erro da última leitura do arquivo, se houver
+func (c *COECatalog) Err() error {
+	c.load()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.file.err
+}
+
+// Underlyings devolve os ativos objeto configurados
+func (c *COECatalog) Underlyings() []COEUnderlying {
+	return c.load().Underlyings
+}
+
+// Templates devolve os modelos de COE configurados
+func (c *COECatalog) Templates() []COETemplate {
+	return c.load().Templates
+}
+
+// Template procura um modelo pelo ID
+func (c *COECatalog) Template(id string) (COETemplate, bool) {
+	for _, t := range c.Templates() {
+		if strings.EqualFold(t.ID, id) {
+			return t, true
+		}
+	}
+	return COETemplate{}, false
+}
+
+// Underlying procura um ativo objeto pelo ID
+func (c *COECatalog) Underlying(id string) (COEUnderlying, bool) {
+	for _, u := range c.Underlyings() {
+		if strings.EqualFold(u.ID, id) {
+			return u, true
+		}
+	}
+	return COEUnderlying{}, false
+}
+
+// Ticker devolve o ticker que representa o ativo objeto (ex: BIG_TECHS -> NASD11.SA).
+// IDs sem cadastro são tratados como o próprio ticker.
+func (c *COECatalog) Ticker(id string) string {
+	if u, ok := c.Underlying(id); ok && u.Proxy != "" {
+		return u.Proxy
+	}
+	return id
+}
@@ -0,0 +1,57 @@
+package catalog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCOECatalogValidatesTemplates(t *testing.T) {
+	underlyings := `"underlyings": [{"id": "SP500", "name": "S&P 500", "proxy": "IVVB11.SA"}]`
+	tests := []struct {
+		name     string
+		template string
+		wantErr  string
+	}{
+		{"válido", `{"id": "t", "underlying": "SP500", "term_months": 24, "participation": 100}`, ""},
+		{"ativo objeto sem diferenciar maiúsculas", `{"id": "t", "underlying": "sp500", "term_months": 24}`, ""},
+		{"sem prazo usa a janela", `{"id": "t", "underlying": "SP500"}`, ""},
+		{"sem ativo objeto", `{"id": "t", "term_months": 24}`, "sem ativo objeto"},
+		{"ativo objeto desconhecido", `{"id": "t", "underlying": "NASDAQ", "term_months": 24}`, `"NASDAQ" não cadastrado`},
+		{"prazo negativo", `{"id": "t", "underlying": "SP500", "term_months": -12}`, "prazo negativo"},
+		{"barreira em fração", `{"id": "t", "underlying": "SP500", "barrier": {"kind": "knock_out", "level": 1.3}}`, "fora da faixa"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "coe.json")
+			if err := os.WriteFile(path, []byte(`{`+underlyings+`, "templates": [`+tt.template+`]}`), 0644); err != nil {
+				t.Fatal(err)
+			}
+			c := NewCOECatalog(path)
+			err := c.Err()
+			if tt.wantErr == "" {
+				if err != nil || len(c.Templates()) != 1 {
+					t.Errorf("erro %v com %d modelos, esperado o modelo carregado", err, len(c.Templates()))
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("erro %v, esperado contendo %q", err, tt.wantErr)
+			}
+			if len(c.Templates()) != 0 {
+				t.Errorf("%d modelos carregados de um arquivo inválido", len(c.Templates()))
+			}
+		})
+	}
+}
+
+func TestCOECatalogShippedConfig(t *testing.T) {
+	c := NewCOECatalog(filepath.Join("..", "..", "config", "coe.json"))
+	if err := c.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Templates()) == 0 {
+		t.Error("nenhum modelo em config/coe.json")
+	}
+}
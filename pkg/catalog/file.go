@@ -0,0 +1,51 @@
+package catalog
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// refreshInterval é o intervalo mínimo entre consultas à data de modificação do arquivo:
+// Lookup e Name rodam várias vezes por requisição e não precisam ir ao disco em cada chamada
+const refreshInterval = time.Second
+
+// watchedFile relê um arquivo de configuração quando a data de modificação muda
+type watchedFile struct {
+	path    string
+	modTime time.Time
+	checked time.Time // Última consulta à data de modificação
+	err     error
+}
+
+// refresh chama decode com o conteúdo do arquivo se ele mudou desde a última leitura bem-sucedida.
+// Se a nova versão estiver inválida, o chamador mantém a última versão boa e o erro fica guardado.
+// A data de modificação é consultada no máximo uma vez por refreshInterval.
+func (f *watchedFile) refresh(decode func(data []byte) error) {
+	now := time.Now()
+	if !f.checked.IsZero() && now.Sub(f.checked) < refreshInterval {
+		return
+	}
+	f.checked = now
+
+	info, err := os.Stat(f.path)
+	if err != nil {
+		f.err = err
+		return
+	}
+	if info.ModTime().Equal(f.modTime) {
+		return
+	}
+
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		f.err = err
+		return
+	}
+	if err := decode(data); err != nil {
+		f.err = fmt.Errorf("%s inválido: %v", f.path, err)
+		return
+	}
+	f.modTime = info.ModTime()
+	f.err = nil
+}
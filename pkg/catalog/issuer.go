@@ -0,0 +1,60 @@
+package catalog
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+)
+
+// Issuer descreve o risco de crédito de um emissor de renda fixa ou COE
+type Issuer struct {
+	Name               string  `json:"name"`
+	Rating             string  `json:"rating,omitempty"`              // Rating de referência (exibição)
+	DefaultProbability float64 `json:"default_probability,omitempty"` // Probabilidade anual de default (%)
+	Recovery           float64 `json:"recovery,omitempty"`            // % recuperado no default
+}
+
+// IssuerCatalog é o cadastro de emissores, relido quando o arquivo muda
+type IssuerCatalog struct {
+	mu      sync.Mutex
+	file    watchedFile
+	issuers []Issuer
+}
+
+// NewIssuerCatalog cria o cadastro de emissores a partir do arquivo informado
+func NewIssuerCatalog(path string) *IssuerCatalog {
+	return &IssuerCatalog{file: watchedFile{path: path}}
+}
+
+// Issuers devolve os emissores cadastrados
+func (c *IssuerCatalog) Issuers() []Issuer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.file.refresh(func(data []byte) error {
+		var issuers []Issuer
+		if err := json.Unmarshal(data, &issuers); err != nil {
+			return err
+		}
+		c.issuers = issuers
+		return nil
+	})
+	return c.issuers
+}
+
+// Err devolve o erro da última leitura do arquivo, se houver
+func (c *IssuerCatalog) Err() error {
+	c.Issuers()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.file.err
+}
+
+// Lookup procura o emissor pelo nome (sem diferenciar maiúsculas)
+func (c *IssuerCatalog) Lookup(name string) (Issuer, bool) {
+	for _, i := range c.Issuers() {
+		if strings.EqualFold(i.Name, name) {
+			return i, true
+		}
+	}
+	return Issuer{}, false
+}
@@ -0,0 +1,131 @@
+package finance
+
+import (
+	"fmt"
+	"sort"
+	"time"
+	_ "time/tzdata" // Garante os fusos das bolsas mesmo em imagens sem zoneinfo (alpine)
+)
+
+// AlignStrategy define como tratar datas da série base ausentes na outra série
+type AlignStrategy string
+
+const (
+	AlignInner       AlignStrategy = "inner"       // Mantém só as datas presentes nas duas séries
+	AlignForwardFill AlignStrategy = "ffill"       // Repete o último valor conhecido (ex: câmbio de feriado)
+	AlignInterpolate AlignStrategy = "interpolate" // Interpola linearmente entre o valor anterior e o seguinte (após o último, repete-o)
+)
+
+// AlignedQuote é uma cotação da série base com o valor correspondente da outra série
+type AlignedQuote struct {
+	Quote
+	Other float64
+}
+
+// AlignReport resume o que o alinhamento fez com os pontos da série base
+type AlignReport struct {
+	Strategy AlignStrategy
+	Matched  int // Datas encontradas nas duas séries
+	Filled   int // Datas preenchidas por forward-fill ou interpolação
+	Dropped  int // Datas descartadas por falta de valor na outra série
+}
+
+// String descreve o alinhamento para exibição ao usuário
+func (r AlignReport) String() string {
+	return fmt.Sprintf("%d pontos alinhados, %d preenchidos (%s), %d descartados", r.Matched, r.Filled, r.Strategy, r.Dropped)
+}
+
+// Align cruza as datas da série base com a série other (ambas em ordem cronológica)
+func Align(base, other []Quote, strategy AlignStrategy) ([]AlignedQuote, AlignReport) {
+	report := AlignReport{Strategy: strategy}
+	aligned := make([]AlignedQuote, 0, len(base))
+
+	j := 0 // Primeiro índice de other com data >= data atual da base
+	for _, q := range base {
+		day := DateKey(q.Date)
+		for j < len(other) && DateKey(other[j].Date) < day {
+			j++
+		}
+
+		if j < len(other) && DateKey(other[j].Date) == day && other[j].Close != 0 {
+			aligned = append(aligned, AlignedQuote{Quote: q, Other: other[j].Close})
+			report.Matched++
+			continue
+		}
+
+		value, ok := fillValue(other, j, q.Date, strategy)
+		if !ok {
+			report.Dropped++
+			continue
+		}
+		aligned = append(aligned, AlignedQuote{Quote: q, Other: value})
+		report.Filled++
+	}
+
+	return aligned, report
+}
+
+// fillValue estima o valor de other na data, sendo next o primeiro índice posterior a ela
+func fillValue(other []Quote, next int, date time.Time, strategy AlignStrategy) (float64, bool) {
+	prev := next - 1
+	for prev >= 0 && other[prev].Close == 0 {
+		prev--
+	}
+	if prev < 0 {
+		return 0, false
+	}
+
+	switch strategy {
+	case AlignForwardFill:
+		return other[prev].Close, true
+	case AlignInterpolate:
+		for next < len(other) && other[next].Close == 0 {
+			next++
+		}
+		if next >= len(other) {
+			// Depois do último ponto não há com o que interpolar: repete o último valor, como o forward-fill
+			return other[prev].Close, true
+		}
+		span := other[next].Date.Sub(other[prev].Date).Hours()
+		if span <= 0 {
+			return other[prev].Close, true
+		}
+		w := date.Sub(other[prev].Date).Hours() / span
+		return other[prev].Close + w*(other[next].Close-other[prev].Close), true
+	}
+	return 0, false
+}
+
+// ValueOn devolve o valor da série na data ou, na falta dele, o último anterior
+func ValueOn(series []Quote, date time.Time) (float64, bool) {
+	day := DateKey(date)
+	i := sort.Search(len(series), func(i int) bool {
+		return DateKey(series[i].Date) > day
+	})
+	if i == 0 {
+		return 0, false
+	}
+	return series[i-1].Close, true
+}
+
+// DateKey devolve a data do pregão (YYYY-MM-DD) usada para cruzar séries
+func DateKey(t time.Time) string {
+	return t.Format("2006-01-02")
+}
+
+// exchangeLocation resolve o fuso da bolsa informado pela Chart API
+func exchangeLocation(name string, gmtOffset int) *time.Location {
+	if name != "" {
+		if loc, err := time.LoadLocation(name); err == nil {
+			return loc
+		}
+	}
+	return time.FixedZone(name, gmtOffset)
+}
+
+// tradingDay converte um timestamp para o dia do pregão no fuso da bolsa,
+// representado como meia-noite UTC, independente do fuso do servidor.
+func tradingDay(ts int64, loc *time.Location) time.Time {
+	y, m, d := time.Unix(ts, 0).In(loc).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
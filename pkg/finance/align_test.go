@@ -0,0 +1,101 @@
+package finance
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// dayQuotes monta uma série com os fechamentos informados, dia a dia
+func dayQuotes(points map[int]float64, days ...int) []Quote {
+	quotes := make([]Quote, len(days))
+	for i, d := range days {
+		quotes[i] = Quote{Date: day(d), Close: points[d]}
+	}
+	return quotes
+}
+
+func TestAlign(t *testing.T) {
+	// Ativo em dias úteis de 2 a 10; câmbio com feriados (6 e 7) e sem os extremos (2 e 10)
+	base := dayQuotes(map[int]float64{2: 10, 3: 11, 6: 12, 7: 13, 8: 14, 9: 15, 10: 16}, 2, 3, 6, 7, 8, 9, 10)
+	fx := dayQuotes(map[int]float64{3: 5.0, 8: 5.5, 9: 6.0}, 3, 8, 9)
+
+	tests := []struct {
+		name     string
+		strategy AlignStrategy
+		want     map[int]float64 // Dia da base -> valor do câmbio alinhado
+		report   AlignReport
+	}{
+		{"inner", AlignInner, map[int]float64{3: 5.0, 8: 5.5, 9: 6.0},
+			AlignReport{Strategy: AlignInner, Matched: 3, Dropped: 4}},
+		{"forward-fill", AlignForwardFill, map[int]float64{3: 5.0, 6: 5.0, 7: 5.0, 8: 5.5, 9: 6.0, 10: 6.0},
+			AlignReport{Strategy: AlignForwardFill, Matched: 3, Filled: 3, Dropped: 1}},
+		// Entre 3 (5.0) e 8 (5.5): dia 6 a 3/5 do caminho, dia 7 a 4/5; depois do último ponto, repete-o
+		{"interpolação", AlignInterpolate, map[int]float64{3: 5.0, 6: 5.3, 7: 5.4, 8: 5.5, 9: 6.0, 10: 6.0},
+			AlignReport{Strategy: AlignInterpolate, Matched: 3, Filled: 3, Dropped: 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			aligned, report := Align(base, fx, tt.strategy)
+			if report != tt.report {
+				t.Errorf("relatório %+v, esperado %+v", report, tt.report)
+			}
+			if len(aligned) != len(tt.want) {
+				t.Fatalf("%d pontos alinhados, esperado %d", len(aligned), len(tt.want))
+			}
+			for _, a := range aligned {
+				want, ok := tt.want[a.Date.Day()]
+				if !ok || math.Abs(a.Other-want) > 1e-9 {
+					t.Errorf("dia %d: câmbio %.4f, esperado %.4f (presente: %v)", a.Date.Day(), a.Other, want, ok)
+				}
+			}
+		})
+	}
+}
+
+func TestAlignSkipsZeroAndShiftedDates(t *testing.T) {
+	// Câmbio zerado no dia 3 conta como ausente; o ativo no fuso de São Paulo cai no mesmo dia do câmbio em UTC
+	sp := time.FixedZone("BRT", -3*3600)
+	base := []Quote{
+		{Date: time.Date(2024, time.January, 2, 21, 0, 0, 0, sp), Close: 10}, // 3 de janeiro em UTC
+		{Date: time.Date(2024, time.January, 3, 17, 0, 0, 0, sp), Close: 11},
+	}
+	fx := dayQuotes(map[int]float64{2: 5.0, 3: 0}, 2, 3)
+
+	aligned, report := Align(base, fx, AlignForwardFill)
+	if report.Matched != 1 || report.Filled != 1 || len(aligned) != 2 {
+		t.Fatalf("relatório %+v, esperado 1 casado e 1 preenchido", report)
+	}
+	if aligned[0].Other != 5.0 || aligned[1].Other != 5.0 {
+		t.Errorf("câmbios %.2f e %.2f, esperado 5.00 nos dois", aligned[0].Other, aligned[1].Other)
+	}
+	if _, report := Align(base, fx, AlignInner); report.Dropped != 1 {
+		t.Errorf("inner descartou %d, esperado 1 (câmbio zerado)", report.Dropped)
+	}
+}
+
+func TestValueOn(t *testing.T) {
+	fx := dayQuotes(map[int]float64{3: 5.0, 8: 5.5}, 3, 8)
+	tests := []struct {
+		name   string
+		date   time.Time
+		want   float64
+		wantOK bool
+	}{
+		{"antes do primeiro ponto", day(2), 0, false},
+		{"no dia", day(3), 5.0, true},
+		{"feriado repete o anterior", day(6), 5.0, true},
+		{"horário no meio do dia", day(8).Add(15 * time.Hour), 5.5, true},
+		{"depois do último ponto", day(20), 5.5, true},
+		{"dia anterior em outro fuso", time.Date(2024, time.January, 7, 22, 0, 0, 0, time.FixedZone("BRT", -3*3600)), 5.0, true},
+	}
+	for _, tt := range tests {
+		got, ok := ValueOn(fx, tt.date)
+		if ok != tt.wantOK || got != tt.want {
+			t.Errorf("%s: %.2f (%v), esperado %.2f (%v)", tt.name, got, ok, tt.want, tt.wantOK)
+		}
+	}
+	if _, ok := ValueOn(nil, day(3)); ok {
+		t.Error("série vazia: esperado sem valor")
+	}
+}
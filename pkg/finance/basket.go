@@ -0,0 +1,240 @@
+package finance
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Rebalance define como os pesos de uma cesta evoluem
+type Rebalance string
+
+const (
+	RebalanceDaily Rebalance = "daily" // Pesos restaurados a cada pregão
+	BuyAndHold     Rebalance = "hold"  // Quantidades fixadas no início; os pesos derivam com os preços
+)
+
+// basketBase é o valor inicial do índice sintético
+const basketBase = 100.0
+
+// BasketComponent é um ativo da cesta com seu peso (em qualquer escala; os pesos são normalizados)
+type BasketComponent struct {
+	Symbol string  `json:"symbol"`
+	Weight float64 `json:"weight"`
+}
+
+// Basket é uma cesta nomeada definida em configuração
+type Basket struct {
+	Components []BasketComponent `json:"components"`
+	Rebalance  Rebalance         `json:"rebalance,omitempty"` // Padrão: o do provedor
+	Currency   string            `json:"currency,omitempty"`  // Padrão: moeda do primeiro componente
+}
+
+// BasketProvider monta um índice sintético a partir de uma cesta ponderada de outros símbolos.
+// O nome pode ser uma expressão ("60*^GSPC+40*FIXED-BRL-10.0") ou o nome de uma cesta de File,
+// um JSON {"nome": {"components": [...], "rebalance": "hold"}} relido a cada busca.
+type BasketProvider struct {
+	Rebalance Rebalance
+	File      string
+}
+
+// basketDepthKey limita cestas que contêm cestas (e evita ciclos entre cestas nomeadas)
+type basketDepthKey struct{}
+
+const maxBasketDepth = 4
+
+// Fetch busca os componentes, converte para a moeda da cesta e compõe o índice (base 100)
+func (p *BasketProvider) Fetch(ctx context.Context, c *Client, name string, startDate, endDate time.Time) (Series, error) {
+	depth, _ := ctx.Value(basketDepthKey{}).(int)
+	if depth >= maxBasketDepth {
+		return Series{}, fmt.Errorf("cesta %q aninhada demais (ciclo?)", name)
+	}
+	ctx = context.WithValue(ctx, basketDepthKey{}, depth+1)
+
+	basket, err := p.resolve(name)
+	if err != nil {
+		return Series{}, err
+	}
+	rebalance := basket.Rebalance
+	if rebalance == "" {
+		rebalance = p.Rebalance
+	}
+
+	var result Series
+	components := make([][]Quote, len(basket.Components))
+	for i, comp := range basket.Components {
+		series, err := c.GetSeries(ctx, comp.Symbol, startDate, endDate)
+		if err != nil {
+			return Series{}, fmt.Errorf("componente %s: %v", comp.Symbol, err)
+		}
+		if i == 0 {
+			result.Currency = series.Currency
+			if basket.Currency != "" {
+				result.Currency = strings.ToUpper(basket.Currency)
+			}
+		}
+		if series, err = c.ConvertSeries(ctx, series, result.Currency, startDate, endDate); err != nil {
+			return Series{}, fmt.Errorf("componente %s: %v", comp.Symbol, err)
+		}
+		if len(series.Quotes) == 0 {
+			return Series{}, fmt.Errorf("componente %s sem dados no período", comp.Symbol)
+		}
+		result.inheritStaleness(series)
+		components[i] = series.Quotes
+	}
+
+	// Calendário do primeiro componente, a partir do dia em que todos têm cotação;
+	// os demais são carregados adiante (feriados de outras bolsas, renda fixa sintética)
+	calendar := components[0]
+	prices := make([][]float64, len(components))
+	for i, quotes := range components {
+		prices[i] = make([]float64, 0, len(calendar))
+		for _, q := range calendar {
+			if v, ok := totalReturnOn(quotes, q.Date); ok {
+				prices[i] = append(prices[i], v)
+			} else {
+				prices[i] = append(prices[i], 0)
+			}
+		}
+	}
+	first := 0
+	for first < len(calendar) && !allPositive(prices, first) {
+		first++
+	}
+	if first == len(calendar) {
+		return Series{}, fmt.Errorf("componentes da cesta %q não têm período em comum", name)
+	}
+
+	weights := normalizedWeights(basket.Components)
+	units := make([]float64, len(weights))
+	for i, w := range weights {
+		units[i] = basketBase * w / prices[i][first]
+	}
+
+	value := basketBase
+	for t := first; t < len(calendar); t++ {
+		if t > first {
+			if rebalance == BuyAndHold {
+				value = 0
+				for i := range weights {
+					value += units[i] * prices[i][t]
+				}
+			} else {
+				change := 0.0
+				for i, w := range weights {
+					change += w * (prices[i][t]/prices[i][t-1] - 1)
+				}
+				value *= 1 + change
+			}
+		}
+		result.Quotes = append(result.Quotes, Quote{
+			Date:     calendar[t].Date,
+			Open:     value,
+			High:     value,
+			Low:      value,
+			Close:    value,
+			AdjClose: value,
+		})
+	}
+	return result, nil
+}
+
+// resolve interpreta o nome como cesta configurada ou como expressão peso*símbolo+...
+func (p *BasketProvider) resolve(name string) (Basket, error) {
+	if p.File != "" && !strings.Contains(name, "*") {
+		data, err := os.ReadFile(p.File)
+		if err != nil {
+			return Basket{}, fmt.Errorf("cesta %q: %v", name, err)
+		}
+		var named map[string]Basket
+		if err := json.Unmarshal(data, &named); err != nil {
+			return Basket{}, fmt.Errorf("%s inválido: %v", p.File, err)
+		}
+		basket, ok := named[name]
+		for key, b := range named {
+			if !ok && strings.EqualFold(key, name) {
+				basket, ok = b, true
+			}
+		}
+		if !ok {
+			return Basket{}, fmt.Errorf("cesta %q não definida em %s", name, p.File)
+		}
+		return basket, validateBasket(basket)
+	}
+	basket, err := ParseBasket(name)
+	if err != nil {
+		return Basket{}, err
+	}
+	return basket, validateBasket(basket)
+}
+
+// ParseBasket interpreta "60*^GSPC+40*FIXED-BRL-10.0"
+func ParseBasket(expr string) (Basket, error) {
+	var basket Basket
+	for _, term := range strings.Split(expr, "+") {
+		parts := strings.SplitN(strings.TrimSpace(term), "*", 2)
+		if len(parts) != 2 {
+			return Basket{}, fmt.Errorf("termo de cesta inválido %q (use peso*símbolo)", term)
+		}
+		weight, err := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+		if err != nil {
+			return Basket{}, fmt.Errorf("peso inválido em %q", term)
+		}
+		basket.Components = append(basket.Components, BasketComponent{
+			Symbol: strings.TrimSpace(parts[1]),
+			Weight: weight,
+		})
+	}
+	return basket, nil
+}
+
+func validateBasket(b Basket) error {
+	if len(b.Components) == 0 {
+		return fmt.Errorf("cesta sem componentes")
+	}
+	for _, comp := range b.Components {
+		if comp.Symbol == "" || comp.Weight <= 0 {
+			return fmt.Errorf("componente inválido %q (peso %.2f)", comp.Symbol, comp.Weight)
+		}
+	}
+	return nil
+}
+
+func normalizedWeights(components []BasketComponent) []float64 {
+	total := 0.0
+	for _, comp := range components {
+		total += comp.Weight
+	}
+	weights := make([]float64, len(components))
+	for i, comp := range components {
+		weights[i] = comp.Weight / total
+	}
+	return weights
+}
+
+// totalReturnOn devolve o fechamento ajustado (com proventos) na data ou no último pregão anterior.
+// A cesta não repassa dividendos, então usa o retorno total dos componentes.
+func totalReturnOn(quotes []Quote, date time.Time) (float64, bool) {
+	day := DateKey(date)
+	i := sort.Search(len(quotes), func(i int) bool {
+		return DateKey(quotes[i].Date) > day
+	})
+	if i == 0 {
+		return 0, false
+	}
+	return totalReturn(quotes[i-1]), true
+}
+
+func allPositive(prices [][]float64, t int) bool {
+	for _, series := range prices {
+		if series[t] <= 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,161 @@
+package finance
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// basketClient serve as séries de teste como "T:<nome>", todas em BRL
+func basketClient(series map[string][]Quote) *Client {
+	static := StaticProvider{}
+	for name, quotes := range series {
+		static[name] = Series{Currency: "BRL", Quotes: quotes}
+	}
+	c := NewClient()
+	c.RegisterProvider("T", static)
+	return c
+}
+
+// valuesOf resume a série como "dia:valor" com duas casas, para comparar nos testes
+func valuesOf(quotes []Quote) string {
+	var parts []string
+	for _, q := range quotes {
+		parts = append(parts, fmt.Sprintf("%d:%.2f", q.Date.Day(), q.Close))
+	}
+	return strings.Join(parts, " ")
+}
+
+func TestBasketRebalance(t *testing.T) {
+	// a dobra e volta; b fica parado
+	c := basketClient(map[string][]Quote{
+		"a": dayQuotes(map[int]float64{2: 100, 3: 200, 4: 200, 5: 100}, 2, 3, 4, 5),
+		"b": dayQuotes(map[int]float64{2: 50, 3: 50, 4: 50, 5: 50}, 2, 3, 4, 5),
+	})
+	tests := []struct {
+		name      string
+		rebalance Rebalance
+		expr      string
+		want      string
+	}{
+		// Pesos restaurados a cada dia: a alta de 100% rende 50%, a queda de 50% tira 25% do que sobrou
+		{"diário", RebalanceDaily, "50*T:a+50*T:b", "2:100.00 3:150.00 4:150.00 5:112.50"},
+		// Quantidades fixas: a cesta volta ao valor inicial junto com a
+		{"buy and hold", BuyAndHold, "50*T:a+50*T:b", "2:100.00 3:150.00 4:150.00 5:100.00"},
+		// Pesos em qualquer escala são normalizados
+		{"pesos normalizados", RebalanceDaily, "1*T:a+1*T:b", "2:100.00 3:150.00 4:150.00 5:112.50"},
+		{"pesos desiguais", BuyAndHold, "3*T:a+1*T:b", "2:100.00 3:175.00 4:175.00 5:100.00"},
+		{"componente único", RebalanceDaily, "7*T:a", "2:100.00 3:200.00 4:200.00 5:100.00"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &BasketProvider{Rebalance: tt.rebalance}
+			series, err := p.Fetch(context.Background(), c, tt.expr, day(1), day(31))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := valuesOf(series.Quotes); got != tt.want {
+				t.Errorf("cesta %s, esperado %s", got, tt.want)
+			}
+			if series.Currency != "BRL" {
+				t.Errorf("moeda %s, esperado BRL", series.Currency)
+			}
+		})
+	}
+}
+
+func TestBasketAlignsComponentDates(t *testing.T) {
+	c := basketClient(map[string][]Quote{
+		// Calendário da cesta: o do primeiro componente
+		"a": dayQuotes(map[int]float64{2: 100, 3: 100, 4: 110, 5: 110, 8: 121}, 2, 3, 4, 5, 8),
+		// b só começa no dia 3, não tem o feriado do dia 4 e cota num sábado (dia 6)
+		"b": dayQuotes(map[int]float64{3: 10, 5: 12, 6: 15, 8: 15}, 3, 5, 6, 8),
+		// c usa o fechamento ajustado (retorno total) quando existe
+		"c": {
+			{Date: day(2), Close: 10, AdjClose: 5},
+			{Date: day(8), Close: 10, AdjClose: 10},
+		},
+	})
+	p := &BasketProvider{Rebalance: BuyAndHold}
+
+	series, err := p.Fetch(context.Background(), c, "1*T:a+1*T:b", day(1), day(31))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Começa no dia 3; no dia 4 b repete 10; no dia 8 b vale 15 (o sábado não vira pregão da cesta)
+	if got, want := valuesOf(series.Quotes), "3:100.00 4:105.00 5:115.00 8:135.50"; got != want {
+		t.Errorf("cesta %s, esperado %s", got, want)
+	}
+
+	series, err = p.Fetch(context.Background(), c, "1*T:a+1*T:c", day(1), day(31))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last := series.Quotes[len(series.Quotes)-1].Close; math.Abs(last-(60.5+100)) > 1e-9 {
+		t.Errorf("cesta com c ajustado termina em %.2f, esperado 160.50", last)
+	}
+}
+
+func TestBasketErrors(t *testing.T) {
+	c := basketClient(map[string][]Quote{
+		"a":    dayQuotes(map[int]float64{2: 100, 3: 100}, 2, 3),
+		"late": dayQuotes(map[int]float64{10: 100}, 10),
+	})
+	p := &BasketProvider{Rebalance: RebalanceDaily}
+	tests := []struct {
+		expr    string
+		wantErr string
+	}{
+		{"T:a", "use peso*símbolo"},
+		{"x*T:a", "peso inválido"},
+		{"0*T:a+1*T:a", "componente inválido"},
+		{"1*T:a+1*", "componente inválido"},
+		{"1*T:a+1*T:none", "componente T:none"},
+		{"1*T:a+1*T:late", "não têm período em comum"},
+	}
+	for _, tt := range tests {
+		if _, err := p.Fetch(context.Background(), c, tt.expr, day(1), day(31)); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s: erro %v, esperado contendo %q", tt.expr, err, tt.wantErr)
+		}
+	}
+}
+
+func TestNamedBaskets(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "baskets.json")
+	config := `{
+		"Meio a Meio": {"components": [{"symbol": "T:a", "weight": 1}, {"symbol": "T:b", "weight": 1}]},
+		"Parada": {"components": [{"symbol": "T:a", "weight": 1}, {"symbol": "T:b", "weight": 1}], "rebalance": "hold"}
+	}`
+	if err := os.WriteFile(file, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	c := basketClient(map[string][]Quote{
+		"a": dayQuotes(map[int]float64{2: 100, 3: 200, 4: 100}, 2, 3, 4),
+		"b": dayQuotes(map[int]float64{2: 50, 3: 50, 4: 50}, 2, 3, 4),
+	})
+	p := &BasketProvider{Rebalance: RebalanceDaily, File: file}
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"meio a meio", "2:100.00 3:150.00 4:112.50"}, // Sem diferenciar maiúsculas, com o rebalanceamento do provedor
+		{"Parada", "2:100.00 3:150.00 4:100.00"},      // O rebalanceamento da cesta prevalece
+	}
+	for _, tt := range tests {
+		series, err := p.Fetch(context.Background(), c, tt.name, day(1), day(31))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if got := valuesOf(series.Quotes); got != tt.want {
+			t.Errorf("%s: cesta %s, esperado %s", tt.name, got, tt.want)
+		}
+	}
+	if _, err := p.Fetch(context.Background(), c, "Outra", day(1), day(31)); err == nil || !strings.Contains(err.Error(), "não definida") {
+		t.Errorf("cesta inexistente: erro %v", err)
+	}
+}
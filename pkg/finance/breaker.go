@@ -0,0 +1,112 @@
+package finance
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen indica que o provedor está falhando e as chamadas foram suspensas
+var ErrCircuitOpen = errors.New("provedor indisponível (circuit breaker aberto)")
+
+// tokenBucket limita a taxa global de requisições
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64 // Tokens por segundo
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return &tokenBucket{rate: rate, burst: float64(burst), tokens: float64(burst), last: time.Now()}
+}
+
+// wait consome um token, esperando a reposição se necessário
+func (b *tokenBucket) wait(ctx context.Context) error {
+	for {
+		b.mu.Lock()
+		now := time.Now()
+		b.tokens += now.Sub(b.last).Seconds() * b.rate
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+		b.last = now
+
+		if b.tokens >= 1 {
+			b.tokens--
+			b.mu.Unlock()
+			return nil
+		}
+		missing := time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+		b.mu.Unlock()
+
+		if err := sleep(ctx, missing); err != nil {
+			return err
+		}
+	}
+}
+
+// circuitBreaker suspende as chamadas após falhas consecutivas.
+// Passado o cooldown, deixa uma chamada de teste passar (meio-aberto).
+type circuitBreaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	failures  int
+	openUntil time.Time
+	probing   bool
+}
+
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	return &circuitBreaker{threshold: threshold, cooldown: cooldown}
+}
+
+// allow indica se a chamada pode seguir e se ela é a chamada de teste do circuito meio-aberto.
+// O valor de probe deve ser devolvido em record ou release, para que só a chamada de teste
+// libere o próximo teste.
+func (cb *circuitBreaker) allow() (ok, probe bool) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if cb.failures < cb.threshold {
+		return true, false
+	}
+	if time.Now().Before(cb.openUntil) || cb.probing {
+		return false, false
+	}
+	cb.probing = true
+	return true, true
+}
+
+// release encerra a chamada sem registrar resultado (ex: cancelamento)
+func (cb *circuitBreaker) release(probe bool) {
+	if !probe {
+		return
+	}
+	cb.mu.Lock()
+	cb.probing = false
+	cb.mu.Unlock()
+}
+
+// record registra o resultado final de uma chamada
+func (cb *circuitBreaker) record(probe, success bool) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if probe {
+		cb.probing = false
+	}
+	if success {
+		cb.failures = 0
+		return
+	}
+	cb.failures++
+	if cb.failures >= cb.threshold {
+		cb.openUntil = time.Now().Add(cb.cooldown)
+	}
+}
@@ -1,181 +1,361 @@
 package finance
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"math"
-	"net/http"
+	"sort"
+	"sync"
 	"time"
 )
 
-// Quote representa um preço histórico em uma data
+// Quote representa um preço histórico em uma data.
+// Date é o dia do pregão no fuso da bolsa, normalizado para meia-noite UTC.
 type Quote struct {
-	Date  time.Time
-	Close float64
+	Date     time.Time
+	Open     float64
+	High     float64
+	Low      float64
+	Close    float64
+	AdjClose float64 // Fechamento ajustado por proventos e desdobramentos (retorno total)
+	Volume   int64
+}
+
+// Scale devolve a cotação com todos os preços multiplicados por f (ex: conversão de moeda)
+func (q Quote) Scale(f float64) Quote {
+	q.Open *= f
+	q.High *= f
+	q.Low *= f
+	q.Close *= f
+	q.AdjClose *= f
+	return q
+}
+
+// Dividend é um provento pago por ação, na moeda de cotação do ativo
+type Dividend struct {
+	Date   time.Time // Data-com (ex-date)
+	Amount float64
+}
+
+// Split é um desdobramento (Numerator > Denominator) ou grupamento de ações
+type Split struct {
+	Date        time.Time
+	Numerator   float64
+	Denominator float64
+}
+
+// Ratio devolve quantas ações novas cada ação antiga vira
+func (s Split) Ratio() float64 {
+	if s.Denominator == 0 {
+		return 1
+	}
+	return s.Numerator / s.Denominator
 }
 
 // Estruturas para parse do JSON da Chart API
 type ChartResponse struct {
 	Chart struct {
 		Result []struct {
+			Meta struct {
+				Currency             string `json:"currency"`
+				Symbol               string `json:"symbol"`
+				ExchangeTimezoneName string `json:"exchangeTimezoneName"`
+				GmtOffset            int    `json:"gmtoffset"`
+			} `json:"meta"`
 			Timestamp  []int64 `json:"timestamp"`
 			Indicators struct {
+				// Ponteiros distinguem null (sem negociação/tick ausente) de zero
 				Quote []struct {
-					Close []float64 `json:"close"`
+					Open   []*float64 `json:"open"`
+					High   []*float64 `json:"high"`
+					Low    []*float64 `json:"low"`
+					Close  []*float64 `json:"close"`
+					Volume []*int64   `json:"volume"`
 				} `json:"quote"`
+				AdjClose []struct {
+					AdjClose []*float64 `json:"adjclose"`
+				} `json:"adjclose"`
 			} `json:"indicators"`
+			Events struct {
+				Dividends map[string]struct {
+					Amount float64 `json:"amount"`
+					Date   int64   `json:"date"`
+				} `json:"dividends"`
+				Splits map[string]struct {
+					Date        int64   `json:"date"`
+					Numerator   float64 `json:"numerator"`
+					Denominator float64 `json:"denominator"`
+				} `json:"splits"`
+			} `json:"events"`
 		} `json:"result"`
 		Error interface{} `json:"error"`
 	} `json:"chart"`
 }
 
 // Client para buscar dados
-type Client struct{}
+type Client struct {
+	// Transport faz as chamadas HTTP (compartilhado para valer o limite de taxa global)
+	Transport *Transport
+	// BaseURL da Chart API; pode apontar para um servidor de teste (httptest)
+	BaseURL string
+	// SearchURL é o endpoint de busca de símbolos do provedor
+	SearchURL string
+
+	// FXAlign define como tratar datas sem câmbio na conversão de moeda
+	FXAlign AlignStrategy
+	// Quality define a verificação e o reparo das séries baixadas
+	Quality QualityRules
+	// Providers atendem símbolos com prefixo (ex: "FILE:meu_fundo"), indexados pelo prefixo
+	Providers map[string]Provider
+	// Cache guarda a última série de cada símbolo para servir dados antigos se o provedor falhar (nil desativa)
+	Cache *FileStore
+	// Memoize guarda as séries já buscadas por este cliente, para clientes que vivem uma só requisição
+	Memoize bool
+
+	memoMu sync.Mutex
+	memo   map[string]Series
+}
 
 // NewClient cria um novo cliente
 func NewClient() *Client {
-	return &Client{}
+	return &Client{
+		Transport: DefaultTransport,
+		BaseURL:   "https://query1.finance.yahoo.com/v8/finance/chart/",
+		SearchURL: "https://query1.finance.yahoo.com/v1/finance/search",
+		FXAlign:   AlignForwardFill,
+		Quality:   DefaultQualityRules(),
+	}
 }
 
-// GetHistoricalData busca dados históricos do Yahoo Finance via Chart API JSON
+// GetHistoricalData busca dados históricos do Yahoo Finance via Chart API JSON.
+// Com useNative=false a série é convertida para USD.
 func (c *Client) GetHistoricalData(symbol string, startDate, endDate time.Time, useNative bool) ([]Quote, error) {
+	target := "USD"
+	if useNative {
+		target = ""
+	}
+	series, err := c.GetHistoricalDataIn(context.Background(), symbol, startDate, endDate, target)
+	if err != nil {
+		return nil, err
+	}
+	return series.Quotes, nil
+}
+
+// GetHistoricalDataIn busca a série do ativo e a converte para a moeda alvo.
+// Moeda alvo vazia mantém a moeda original de cotação.
+func (c *Client) GetHistoricalDataIn(ctx context.Context, symbol string, startDate, endDate time.Time, currency string) (Series, error) {
+	series, err := c.GetSeries(ctx, symbol, startDate, endDate)
+	if err != nil {
+		return Series{}, err
+	}
+	if currency == "" {
+		return series, nil
+	}
+	return c.ConvertSeries(ctx, series, currency, startDate, endDate)
+}
+
+// GetSeries busca o histórico de um ativo na sua moeda de cotação.
+// O contexto (normalmente o da requisição HTTP de origem) cancela as chamadas ao provedor.
+func (c *Client) GetSeries(ctx context.Context, symbol string, startDate, endDate time.Time) (Series, error) {
+	if !c.Memoize {
+		return c.fetchSeries(ctx, symbol, startDate, endDate)
+	}
+
+	key := fmt.Sprintf("%s|%s|%s", symbol, DateKey(startDate), DateKey(endDate))
+	c.memoMu.Lock()
+	series, ok := c.memo[key]
+	c.memoMu.Unlock()
+	if ok {
+		return series, nil
+	}
+
+	series, err := c.fetchSeries(ctx, symbol, startDate, endDate)
+	if err != nil {
+		return Series{}, err
+	}
+	c.memoMu.Lock()
+	if c.memo == nil {
+		c.memo = make(map[string]Series)
+	}
+	c.memo[key] = series
+	c.memoMu.Unlock()
+	return series, nil
+}
+
+// fetchSeries busca a série no provedor do símbolo, sem memoização
+func (c *Client) fetchSeries(ctx context.Context, symbol string, startDate, endDate time.Time) (Series, error) {
+	// Provedores registrados por prefixo (arquivos locais, uploads, sintéticos)
+	if p, name, ok := c.providerFor(symbol); ok {
+		series, err := p.Fetch(ctx, c, name, startDate, endDate)
+		if err != nil {
+			return Series{}, err
+		}
+		series.Symbol = symbol
+		return series, nil
+	}
+
 	// Lógica para Ativos Sintéticos de Renda Fixa Brasileira
-	// Ex: FIXED-BRL-6 -> Renda Fixa 6% a.a. em BRL convertida para USD
+	// Ex: FIXED-BRL-6 -> Renda Fixa 6% a.a. em BRL
 	if len(symbol) > 10 && symbol[:10] == "FIXED-BRL-" {
 		rateStr := symbol[10:]
 		var annualRate float64
 		fmt.Sscanf(rateStr, "%f", &annualRate)
-		
-		return c.getSyntheticFixedIncomeData(annualRate, startDate, endDate, useNative)
-	}
 
-	// Lógica para Ações Brasileiras (.SA) - Conversão automática para USD
-	if len(symbol) > 3 && symbol[len(symbol)-3:] == ".SA" {
-		return c.getBrazilianStockInUSD(symbol, startDate, endDate, useNative)
+		return c.getSyntheticFixedIncomeData(ctx, symbol, annualRate, startDate, endDate)
 	}
 
 	period1 := startDate.Unix()
 	period2 := endDate.Unix()
 
 	// URL da Chart API (API v8) - geralmente mais permissiva que v7/download
-	url := fmt.Sprintf("https://query1.finance.yahoo.com/v8/finance/chart/%s?period1=%d&period2=%d&interval=1d", symbol, period1, period2)
+	url := fmt.Sprintf("%s%s?period1=%d&period2=%d&interval=1d&events=div%%7Csplit", c.BaseURL, symbol, period1, period2)
 
-	return c.fetchRawQuotes(url)
-}
-
-
-
-// getBrazilianStockInUSD busca a ação em BRL e converte para USD usando o câmbio do dia
-func (c *Client) getBrazilianStockInUSD(symbol string, startDate, endDate time.Time, useNative bool) ([]Quote, error) {
-	period1 := startDate.Unix()
-	period2 := endDate.Unix()
-	url := fmt.Sprintf("https://query1.finance.yahoo.com/v8/finance/chart/%s?period1=%d&period2=%d&interval=1d", symbol, period1, period2)
-	
-	stockQuotes, err := c.fetchRawQuotes(url)
+	series, err := c.fetchRawQuotes(ctx, url)
 	if err != nil {
-		return nil, fmt.Errorf("erro ao buscar %s original: %v", symbol, err)
+		return c.staleFallback(ctx, symbol, startDate, endDate, err)
 	}
-
-	// Se o usuário quer moeda original, retornamos direto o preço em Reais
-	if useNative {
-		return stockQuotes, nil
+	series.Symbol = symbol
+	if series.Currency == "" {
+		series.Currency = guessCurrency(symbol)
 	}
-	
-	// 2. Buscar Câmbio (BRL=X)
-	exchangeQuotes, err := c.GetHistoricalData("BRL=X", startDate, endDate, false) // recursão evita loop pq simbolo != .SA e FIXED
-	if err != nil {
-		// Fallback ou erro?
-		// Vamos retornar erro, pois o usuário quer comparacao em USD
-		return nil, fmt.Errorf("erro ao obter câmbio: %v", err)
-	}
-
-	// 3. Cruzar dados e converter e alinhar datas
-	// Mapa de câmbio para acesso rápido por data (YYYY-MM-DD)
-	exchangeMap := make(map[string]float64)
-	for _, q := range exchangeQuotes {
-		key := q.Date.Format("2006-01-02")
-		exchangeMap[key] = q.Close
-	}
-
-	var convertedQuotes []Quote
-	for _, sq := range stockQuotes {
-		key := sq.Date.Format("2006-01-02")
-		rate, ok := exchangeMap[key]
-		
-		if !ok || rate == 0 {
-			continue
+	// O cache guarda a série bruta: os reparos de Clean dependem das regras de cada requisição
+	if c.Cache != nil {
+		if err := c.Cache.Save(series); err != nil {
+			fmt.Printf("Erro ao gravar cache de %s: %v\n", symbol, err)
 		}
-		
-		convertedPrice := sq.Close / rate
-		convertedQuotes = append(convertedQuotes, Quote{
-			Date: sq.Date,
-			Close: convertedPrice,
-		})
 	}
-	
-	return convertedQuotes, nil
+	return c.cleaned(series), nil
 }
 
-// fetchRawQuotes encapsula a chamada HTTP básica ao Yahoo para reutilização
-func (c *Client) fetchRawQuotes(url string) ([]Quote, error) {
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, err
+// cleaned aplica as regras de qualidade do cliente a uma série bruta do Yahoo ou do cache
+func (c *Client) cleaned(series Series) Series {
+	quotes, quality := Clean(series.Quotes, c.Quality)
+	if series.Quality != nil {
+		quality.PartialNulls = series.Quality.PartialNulls
 	}
-	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36")
+	series.Quotes, series.Quality = quotes, quality
+	return series
+}
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+// staleFallback devolve a última série conhecida do símbolo quando a busca ao vivo falha.
+// Sem cache (ou com a requisição cancelada) devolve o erro original.
+func (c *Client) staleFallback(ctx context.Context, symbol string, startDate, endDate time.Time, fetchErr error) (Series, error) {
+	if c.Cache == nil || ctx.Err() != nil {
+		return Series{}, fetchErr
+	}
+	series, err := c.Cache.Load(symbol, startDate, endDate)
 	if err != nil {
-		return nil, err
+		return Series{}, fetchErr
 	}
-	defer resp.Body.Close()
+	series = c.cleaned(series)
+	if len(series.Quotes) == 0 {
+		return Series{}, fetchErr
+	}
+	fmt.Printf("Usando cache de %s (dados de %s): %v\n", symbol, series.AsOf.Format("2006-01-02"), fetchErr)
+	return series, nil
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("status %d", resp.StatusCode)
+// fetchRawQuotes encapsula a chamada HTTP básica ao Yahoo para reutilização.
+// As cotações vêm como o provedor mandou, sem Clean; Quality traz só PartialNulls.
+func (c *Client) fetchRawQuotes(ctx context.Context, url string) (Series, error) {
+	transport := c.Transport
+	if transport == nil {
+		transport = DefaultTransport
+	}
+	body, err := transport.Get(ctx, url)
+	if err != nil {
+		return Series{}, err
 	}
 
 	var chartResp ChartResponse
-	if err := json.NewDecoder(resp.Body).Decode(&chartResp); err != nil {
-		return nil, err
+	if err := json.Unmarshal(body, &chartResp); err != nil {
+		return Series{}, err
 	}
 
 	if len(chartResp.Chart.Result) == 0 {
-		return nil, fmt.Errorf("sem dados")
+		return Series{}, fmt.Errorf("sem dados")
 	}
 
 	result := chartResp.Chart.Result[0]
+	if len(result.Indicators.Quote) == 0 {
+		return Series{}, fmt.Errorf("sem dados")
+	}
 	timestamps := result.Timestamp
-	closes := result.Indicators.Quote[0].Close
-	
-	minLen := len(timestamps)
-	if len(closes) < minLen {
-		minLen = len(closes)
+	ohlcv := result.Indicators.Quote[0]
+
+	// Moedas cotadas em subunidades (ex: GBp = pence) são normalizadas
+	currency, scale := normalizeCurrency(result.Meta.Currency)
+
+	// Datas no fuso da bolsa, para que feriados e fusos diferentes não desalinhem as séries
+	loc := exchangeLocation(result.Meta.ExchangeTimezoneName, result.Meta.GmtOffset)
+
+	var adjCloses []*float64
+	if len(result.Indicators.AdjClose) > 0 {
+		adjCloses = result.Indicators.AdjClose[0].AdjClose
 	}
-	
-	var quotes []Quote
-	for i := 0; i < minLen; i++ {
-		if closes[i] == 0 { continue }
+
+	// Cada campo é lido pelo índice do timestamp; nulls não deslocam os demais campos.
+	// Fechamento nulo vira NaN para ser tratado por Clean conforme as regras do cliente.
+	partialNulls := 0
+	quotes := make([]Quote, 0, len(timestamps))
+	for i, ts := range timestamps {
+		price, ok := valueAt(ohlcv.Close, i)
+		if !ok {
+			price = math.NaN()
+		}
+		adj, ok := valueAt(adjCloses, i)
+		if !ok || adj == 0 {
+			adj = price
+		}
+		open, okOpen := valueAt(ohlcv.Open, i)
+		high, okHigh := valueAt(ohlcv.High, i)
+		low, okLow := valueAt(ohlcv.Low, i)
+		volume, okVolume := volumeAt(ohlcv.Volume, i)
+		if !math.IsNaN(price) && !(okOpen && okHigh && okLow && okVolume) {
+			partialNulls++
+		}
+
 		quotes = append(quotes, Quote{
-			Date: time.Unix(timestamps[i], 0),
-			Close: closes[i],
+			Date:     tradingDay(ts, loc),
+			Open:     open * scale,
+			High:     high * scale,
+			Low:      low * scale,
+			Close:    price * scale,
+			AdjClose: adj * scale,
+			Volume:   volume,
 		})
 	}
-	return quotes, nil
+
+	// Eventos vêm como mapas indexados por timestamp; ordenamos por data
+	var dividends []Dividend
+	for _, d := range result.Events.Dividends {
+		dividends = append(dividends, Dividend{Date: tradingDay(d.Date, loc), Amount: d.Amount * scale})
+	}
+	sortDividends(dividends)
+
+	var splits []Split
+	for _, sp := range result.Events.Splits {
+		splits = append(splits, Split{Date: tradingDay(sp.Date, loc), Numerator: sp.Numerator, Denominator: sp.Denominator})
+	}
+	sortSplits(splits)
+
+	return Series{Currency: currency, Quotes: quotes, Dividends: dividends, Splits: splits, Quality: &QualityReport{PartialNulls: partialNulls}}, nil
 }
 
-// getSyntheticFixedIncomeData gera dados para um ativo de renda fixa em BRL convertido para USD
-func (c *Client) getSyntheticFixedIncomeData(annualRatePercent float64, startDate, endDate time.Time, useNative bool) ([]Quote, error) {
+// getSyntheticFixedIncomeData gera dados para um ativo de renda fixa em BRL
+func (c *Client) getSyntheticFixedIncomeData(ctx context.Context, symbol string, annualRatePercent float64, startDate, endDate time.Time) (Series, error) {
 	// 1. Obter histórico do Câmbio (USD/BRL) -> BRL=X
-	// Precisamos das datas para saber quais dias de "mercado" existem, mesmo se useNative=true.
+	// Precisamos das datas para saber quais dias de "mercado" existem.
 	// Usar BRL=X como proxy de dias úteis/mercado é razoável.
-	exchangeQuotes, err := c.GetHistoricalData("BRL=X", startDate, endDate, false)
+	exchange, err := c.GetSeries(ctx, "BRL=X", startDate, endDate)
 	if err != nil {
-		return nil, fmt.Errorf("erro ao obter câmbio para cálculo sintético: %v", err)
+		return Series{}, fmt.Errorf("erro ao obter câmbio para cálculo sintético: %v", err)
 	}
+	exchangeQuotes := exchange.Quotes
 
 	if len(exchangeQuotes) == 0 {
-		return nil, fmt.Errorf("sem dados de câmbio para o período")
+		return Series{}, fmt.Errorf("sem dados de câmbio para o período")
 	}
 
 	// 2. Calcular taxa diária
@@ -184,40 +364,57 @@ func (c *Client) getSyntheticFixedIncomeData(annualRatePercent float64, startDat
 	dailyRate := pow(1+annualRatePercent/100.0, 1.0/365.0) - 1.0
 
 	var quotes []Quote
-	
+
 	// Valor inicial arbitrário em BRL (ex: 100).
 	firstDate := exchangeQuotes[0].Date
-	
+
 	for _, eq := range exchangeQuotes {
 		// Dias passados desde o início da série
 		daysPassed := eq.Date.Sub(firstDate).Hours() / 24.0
 		if daysPassed < 0 {
 			daysPassed = 0
 		}
-		
+
 		// Rendimento acumulado exato até esta data
 		// Value = Initial * (1+Daily)^Days
-		accumulatedBRL := 100.0 * pow(1+dailyRate, daysPassed)
-		
-		var finalValue float64
-		if useNative {
-			finalValue = accumulatedBRL
-		} else {
-			// Converter para USD
-			rateBRLUSD := eq.Close
-			if rateBRLUSD == 0 {
-				continue
-			}
-			finalValue = accumulatedBRL / rateBRLUSD
-		}
-		
+		value := 100.0 * pow(1+dailyRate, daysPassed)
 		quotes = append(quotes, Quote{
-			Date:  eq.Date,
-			Close: finalValue,
+			Date:     eq.Date,
+			Open:     value,
+			High:     value,
+			Low:      value,
+			Close:    value,
+			AdjClose: value,
 		})
 	}
 
-	return quotes, nil
+	// A conversão para outra moeda fica a cargo de ConvertSeries
+	series := Series{Symbol: symbol, Currency: "BRL", Quotes: quotes}
+	series.inheritStaleness(exchange)
+	return series, nil
+}
+
+func sortDividends(dividends []Dividend) {
+	sort.Slice(dividends, func(i, j int) bool { return dividends[i].Date.Before(dividends[j].Date) })
+}
+
+func sortSplits(splits []Split) {
+	sort.Slice(splits, func(i, j int) bool { return splits[i].Date.Before(splits[j].Date) })
+}
+
+// valueAt lê o índice i de um campo da Chart API, que pode ser null ou vir mais curto que os timestamps
+func valueAt(values []*float64, i int) (float64, bool) {
+	if i < len(values) && values[i] != nil {
+		return *values[i], true
+	}
+	return 0, false
+}
+
+func volumeAt(values []*int64, i int) (int64, bool) {
+	if i < len(values) && values[i] != nil {
+		return *values[i], true
+	}
+	return 0, false
 }
 
 func pow(x, y float64) float64 {
@@ -0,0 +1,207 @@
+package finance
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CSVFormat descreve o layout de um arquivo de preços date/close
+type CSVFormat struct {
+	Delimiter    string `json:"delimiter"`     // "," (padrão) ou ";" em exports pt-BR
+	DateFormat   string `json:"date_format"`   // Layout Go, ex: "2006-01-02" (padrão) ou "02/01/2006"
+	DecimalComma bool   `json:"decimal_comma"` // "1.234,56" em vez de "1234.56"
+	Currency     string `json:"currency"`      // Moeda dos preços (padrão BRL)
+}
+
+// withDefaults preenche os campos não informados
+func (f CSVFormat) withDefaults() CSVFormat {
+	if f.Delimiter == "" {
+		f.Delimiter = ","
+	}
+	if f.DateFormat == "" {
+		f.DateFormat = "2006-01-02"
+	}
+	if f.Currency == "" {
+		f.Currency = "BRL"
+	}
+	return f
+}
+
+// Nomes de coluna reconhecidos no cabeçalho
+var (
+	dateColumns  = []string{"date", "data", "dt", "dt_comptc"}
+	closeColumns = []string{"close", "fechamento", "cota", "valor", "price", "preco", "preço", "vl_quota"}
+)
+
+// ParseCSV lê um CSV com colunas de data e fechamento. O cabeçalho é opcional;
+// sem ele (ou sem colunas reconhecidas) usa a primeira coluna como data e a segunda como preço.
+func ParseCSV(r io.Reader, format CSVFormat) ([]Quote, error) {
+	format = format.withDefaults()
+	reader := csv.NewReader(r)
+	reader.Comma = []rune(format.Delimiter)[0]
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	dateCol, closeCol := 0, 1
+	line := 1 // Linha do arquivo do primeiro registro, para as mensagens de erro
+	if len(records) > 0 {
+		if _, err := time.Parse(format.DateFormat, strings.TrimSpace(records[0][0])); err != nil {
+			// Primeira linha é cabeçalho
+			dateCol, closeCol = headerColumns(records[0])
+			records = records[1:]
+			line++
+		}
+	}
+
+	quotes := make([]Quote, 0, len(records))
+	for i, rec := range records {
+		if len(rec) <= dateCol || len(rec) <= closeCol {
+			continue
+		}
+		date, err := time.Parse(format.DateFormat, strings.TrimSpace(rec[dateCol]))
+		if err != nil {
+			return nil, fmt.Errorf("linha %d: data inválida %q", line+i, rec[dateCol])
+		}
+		price, err := parseDecimal(rec[closeCol], format.DecimalComma)
+		if err != nil {
+			return nil, fmt.Errorf("linha %d: preço inválido %q", line+i, rec[closeCol])
+		}
+		quotes = append(quotes, Quote{
+			Date:     time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC),
+			Open:     price,
+			High:     price,
+			Low:      price,
+			Close:    price,
+			AdjClose: price,
+		})
+	}
+	return quotes, nil
+}
+
+// headerColumns localiza as colunas de data e preço pelo nome
+func headerColumns(header []string) (int, int) {
+	dateCol, closeCol := 0, 1
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if containsString(dateColumns, name) {
+			dateCol = i
+		}
+		if containsString(closeColumns, name) {
+			closeCol = i
+		}
+	}
+	return dateCol, closeCol
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// parseDecimal converte números com ponto ou vírgula decimal; vazio vira NaN (tratado por Clean)
+func parseDecimal(s string, decimalComma bool) (float64, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return math.NaN(), nil
+	}
+	if decimalComma {
+		s = strings.ReplaceAll(s, ".", "")
+		s = strings.ReplaceAll(s, ",", ".")
+	}
+	return strconv.ParseFloat(s, 64)
+}
+
+// jsonQuote é o formato aceito em arquivos .json: [{"date": "2020-01-02", "close": 10.5}]
+type jsonQuote struct {
+	Date  string  `json:"date"`
+	Close float64 `json:"close"`
+}
+
+// ParseJSONQuotes lê um array JSON de {date, close}
+func ParseJSONQuotes(r io.Reader, format CSVFormat) ([]Quote, error) {
+	format = format.withDefaults()
+	var rows []jsonQuote
+	if err := json.NewDecoder(r).Decode(&rows); err != nil {
+		return nil, err
+	}
+	quotes := make([]Quote, 0, len(rows))
+	for _, row := range rows {
+		date, err := time.Parse(format.DateFormat, row.Date)
+		if err != nil {
+			return nil, fmt.Errorf("data inválida %q", row.Date)
+		}
+		quotes = append(quotes, Quote{
+			Date:     time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC),
+			Open:     row.Close,
+			High:     row.Close,
+			Low:      row.Close,
+			Close:    row.Close,
+			AdjClose: row.Close,
+		})
+	}
+	return quotes, nil
+}
+
+// FileProvider carrega históricos próprios (fundos, ativos privados, índices internos)
+// de um diretório: <nome>.csv ou <nome>.json, endereçados como "FILE:<nome>".
+// Um arquivo opcional <nome>.format.json sobrepõe o formato padrão daquele arquivo.
+type FileProvider struct {
+	Dir    string
+	Format CSVFormat
+}
+
+// Fetch lê o arquivo do símbolo, aplica as regras de qualidade do cliente e recorta o período
+func (p *FileProvider) Fetch(ctx context.Context, c *Client, name string, startDate, endDate time.Time) (Series, error) {
+	// Evita que o nome escape do diretório de dados (ex: FILE:../../etc/passwd)
+	if name == "" || strings.ContainsAny(name, `/\`) || strings.Contains(name, "..") {
+		return Series{}, fmt.Errorf("nome de arquivo inválido: %q", name)
+	}
+
+	format := p.Format
+	if data, err := os.ReadFile(filepath.Join(p.Dir, name+".format.json")); err == nil {
+		if err := json.Unmarshal(data, &format); err != nil {
+			return Series{}, fmt.Errorf("formato de %s inválido: %v", name, err)
+		}
+	}
+	format = format.withDefaults()
+
+	var quotes []Quote
+	if f, err := os.Open(filepath.Join(p.Dir, name+".csv")); err == nil {
+		defer f.Close()
+		if quotes, err = ParseCSV(f, format); err != nil {
+			return Series{}, fmt.Errorf("%s.csv: %v", name, err)
+		}
+	} else if f, err := os.Open(filepath.Join(p.Dir, name+".json")); err == nil {
+		defer f.Close()
+		if quotes, err = ParseJSONQuotes(f, format); err != nil {
+			return Series{}, fmt.Errorf("%s.json: %v", name, err)
+		}
+	} else {
+		return Series{}, fmt.Errorf("arquivo de preços %q não encontrado em %s", name, p.Dir)
+	}
+
+	quotes, quality := Clean(quotes, c.Quality)
+	return Series{
+		Currency: format.Currency,
+		Quotes:   quotesBetween(quotes, startDate, endDate),
+		Quality:  quality,
+	}, nil
+}
@@ -0,0 +1,127 @@
+package finance
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestParseCSV(t *testing.T) {
+	ptBR := CSVFormat{Delimiter: ";", DateFormat: "02/01/2006", DecimalComma: true}
+	tests := []struct {
+		name    string
+		input   string
+		format  CSVFormat
+		want    string // Série no formato de closesOf
+		wantErr string // Trecho esperado da mensagem de erro
+	}{
+		{"vírgula com cabeçalho", "date,close\n2024-01-02,10.5\n2024-01-03,11\n", CSVFormat{}, "[2:10 3:11]", ""},
+		{"sem cabeçalho", "2024-01-02,10\n2024-01-03,11\n", CSVFormat{}, "[2:10 3:11]", ""},
+		{"ponto e vírgula pt-BR", "Data;Cota\n02/01/2024;1.234,56\n03/01/2024;1.300,00\n", ptBR, "[2:1235 3:1300]", ""},
+		{"colunas pelo nome", "volume,Fechamento,Data\n500,10,2024-01-02\n600,11,2024-01-03\n", CSVFormat{}, "[2:10 3:11]", ""},
+		{"colunas do informe da CVM", "CNPJ_FUNDO;DT_COMPTC;VL_QUOTA\nx;2024-01-02;1.5\n", CSVFormat{Delimiter: ";"}, "[2:2]", ""},
+		{"cabeçalho desconhecido usa as duas primeiras", "quando,quanto\n2024-01-02,10\n", CSVFormat{}, "[2:10]", ""},
+		{"espaços ao redor", "date, close\n 2024-01-02 , 10 \n", CSVFormat{}, "[2:10]", ""},
+		{"linha curta é ignorada", "date,close\n2024-01-02\n2024-01-03,11\n", CSVFormat{}, "[3:11]", ""},
+		{"vazio", "", CSVFormat{}, "[]", ""},
+		{"data inválida", "date,close\n2024-01-02,10\n2024-13-01,11\n", CSVFormat{}, "", `linha 3: data inválida "2024-13-01"`},
+		{"preço inválido", "2024-01-02,10\n2024-01-03,abc\n", CSVFormat{}, "", `linha 2: preço inválido "abc"`},
+		{"vírgula decimal sem o formato", "Data;Cota\n02/01/2024;1.234,56\n", CSVFormat{Delimiter: ";", DateFormat: "02/01/2006"}, "", "linha 2: preço inválido"},
+		{"aspas malformadas", "date,close\n2024-01-02,\"10\n", CSVFormat{}, "", "quote"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			quotes, err := ParseCSV(strings.NewReader(tt.input), tt.format)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("erro %v, esperado contendo %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("erro inesperado: %v", err)
+			}
+			if got := closesOf(quotes); got != tt.want {
+				t.Errorf("série %s, esperado %s", got, tt.want)
+			}
+			for _, q := range quotes {
+				if q.Date.Location().String() != "UTC" || q.Date.Hour() != 0 || q.Open != q.Close || q.AdjClose != q.Close {
+					t.Errorf("cotação %+v: esperado meia-noite UTC e OHLC igual ao fechamento", q)
+				}
+			}
+		})
+	}
+}
+
+func TestParseCSVKeepsEmptyPriceAsNull(t *testing.T) {
+	quotes, err := ParseCSV(strings.NewReader("2024-01-02,10\n2024-01-03,\n"), CSVFormat{})
+	if err != nil || len(quotes) != 2 || !math.IsNaN(quotes[1].Close) {
+		t.Fatalf("cotações %+v (erro %v), esperado o segundo fechamento nulo", quotes, err)
+	}
+	// O nulo chega ao Clean, que o conta e descarta
+	if _, report := Clean(quotes, DefaultQualityRules()); report.Nulls != 1 {
+		t.Errorf("%d nulos, esperado 1", report.Nulls)
+	}
+}
+
+func TestParseDecimal(t *testing.T) {
+	tests := []struct {
+		input        string
+		decimalComma bool
+		want         float64
+		wantErr      bool
+	}{
+		{"1234.56", false, 1234.56, false},
+		{"1.234,56", true, 1234.56, false},
+		{"1.234.567,8", true, 1234567.8, false},
+		{"12,5", true, 12.5, false},
+		{" 42 ", false, 42, false},
+		{"-3.5", false, -3.5, false},
+		{"1,234.56", false, 0, true}, // Separador de milhar em inglês não é aceito
+		{"1.234,56", false, 0, true},
+		{"abc", true, 0, true},
+	}
+	for _, tt := range tests {
+		got, err := parseDecimal(tt.input, tt.decimalComma)
+		if (err != nil) != tt.wantErr || (!tt.wantErr && math.Abs(got-tt.want) > 1e-9) {
+			t.Errorf("parseDecimal(%q, %v) = %v, %v; esperado %v (erro: %v)", tt.input, tt.decimalComma, got, err, tt.want, tt.wantErr)
+		}
+	}
+	if got, err := parseDecimal("  ", false); err != nil || !math.IsNaN(got) {
+		t.Errorf("vazio = %v, %v; esperado NaN", got, err)
+	}
+}
+
+func TestParseJSONQuotes(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		format  CSVFormat
+		want    string
+		wantErr string
+	}{
+		{"padrão", `[{"date": "2024-01-02", "close": 10}, {"date": "2024-01-03", "close": 11.2}]`, CSVFormat{}, "[2:10 3:11]", ""},
+		{"formato de data próprio", `[{"date": "02/01/2024", "close": 10}]`, CSVFormat{DateFormat: "02/01/2006"}, "[2:10]", ""},
+		{"vazio", `[]`, CSVFormat{}, "[]", ""},
+		{"data inválida", `[{"date": "ontem", "close": 10}]`, CSVFormat{}, "", `data inválida "ontem"`},
+		{"preço como texto", `[{"date": "2024-01-02", "close": "10"}]`, CSVFormat{}, "", "cannot unmarshal"},
+		{"JSON malformado", `[{"date": "2024-01-02"`, CSVFormat{}, "", "EOF"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			quotes, err := ParseJSONQuotes(strings.NewReader(tt.input), tt.format)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("erro %v, esperado contendo %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("erro inesperado: %v", err)
+			}
+			if got := closesOf(quotes); got != tt.want {
+				t.Errorf("série %s, esperado %s", got, tt.want)
+			}
+		})
+	}
+}
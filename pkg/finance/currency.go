@@ -0,0 +1,174 @@
+package finance
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Series agrupa as cotações de um ativo com a moeda em que estão expressas
+type Series struct {
+	Symbol   string
+	Currency string // Código ISO (USD, BRL, EUR...)
+	Quotes   []Quote
+
+	// Eventos corporativos. Os preços do Yahoo já vêm ajustados por desdobramentos,
+	// assim como o valor dos proventos.
+	Dividends []Dividend
+	Splits    []Split
+
+	// QuoteCurrency guarda a moeda original de negociação quando a série foi convertida
+	QuoteCurrency string
+
+	// Alignment resume o cruzamento com o câmbio quando a série foi convertida
+	Alignment *AlignReport
+	// Quality resume a verificação de qualidade feita ao baixar a série
+	Quality *QualityReport
+
+	// Stale indica que o provedor falhou e a série veio do cache local, com dados até AsOf
+	Stale bool
+	AsOf  time.Time
+}
+
+// inheritStaleness marca a série como desatualizada se a série de origem (ex: câmbio) estiver
+func (s *Series) inheritStaleness(src Series) {
+	if !src.Stale {
+		return
+	}
+	if !s.Stale || src.AsOf.Before(s.AsOf) {
+		s.AsOf = src.AsOf
+	}
+	s.Stale = true
+}
+
+// OriginalCurrency devolve a moeda em que o ativo é negociado, mesmo após conversões
+func (s Series) OriginalCurrency() string {
+	if s.QuoteCurrency != "" {
+		return s.QuoteCurrency
+	}
+	return s.Currency
+}
+
+// SupportedCurrencies lista as moedas de relatório oferecidas na interface
+var SupportedCurrencies = []string{"USD", "BRL", "EUR"}
+
+// subunitCurrencies mapeia moedas cotadas em subunidades pelo Yahoo (ex: ações de Londres em pence)
+var subunitCurrencies = map[string]struct {
+	Code  string
+	Scale float64
+}{
+	"GBp": {"GBP", 0.01},
+	"GBX": {"GBP", 0.01},
+	"ZAc": {"ZAR", 0.01},
+	"ILA": {"ILS", 0.01},
+}
+
+// normalizeCurrency devolve o código ISO e o fator para converter o preço para a unidade principal
+func normalizeCurrency(code string) (string, float64) {
+	if sub, ok := subunitCurrencies[code]; ok {
+		return sub.Code, sub.Scale
+	}
+	return strings.ToUpper(code), 1
+}
+
+// guessCurrency infere a moeda de cotação pelo símbolo quando a API não informa
+func guessCurrency(symbol string) string {
+	switch {
+	case strings.HasSuffix(symbol, ".SA"):
+		return "BRL"
+	case strings.HasSuffix(symbol, "=X"):
+		// BRL=X -> cotado em BRL; EURUSD=X -> cotado em USD
+		pair := strings.TrimSuffix(symbol, "=X")
+		if len(pair) >= 3 {
+			return pair[len(pair)-3:]
+		}
+	}
+	return "USD"
+}
+
+// fxPair devolve o símbolo do par de câmbio e se a cotação deve ser dividida (true) ou multiplicada
+func fxPair(from, to string) (string, bool) {
+	switch {
+	case to == "USD":
+		// BRL=X = reais por dólar -> preço em BRL / taxa = preço em USD
+		return from + "=X", true
+	case from == "USD":
+		// EUR=X = euros por dólar -> preço em USD * taxa = preço em EUR
+		return to + "=X", false
+	default:
+		// Par cruzado: BRLEUR=X = euros por real
+		return from + to + "=X", false
+	}
+}
+
+// GetFXRates devolve a série de câmbio expressa em unidades de "to" por unidade de "from"
+func (c *Client) GetFXRates(ctx context.Context, from, to string, startDate, endDate time.Time) (Series, error) {
+	from, to = strings.ToUpper(from), strings.ToUpper(to)
+	pair, divide := fxPair(from, to)
+	fx, err := c.GetSeries(ctx, pair, startDate, endDate)
+	if err != nil {
+		return Series{}, fmt.Errorf("erro ao obter câmbio %s: %v", pair, err)
+	}
+
+	rates := make([]Quote, 0, len(fx.Quotes))
+	for _, q := range fx.Quotes {
+		if q.Close == 0 {
+			continue
+		}
+		rate := q.Close
+		if divide {
+			rate = 1 / q.Close
+		}
+		rates = append(rates, Quote{Date: q.Date, Close: rate})
+	}
+
+	return Series{Symbol: pair, Currency: to, Quotes: rates, Stale: fx.Stale, AsOf: fx.AsOf}, nil
+}
+
+// ConvertSeries converte uma série para a moeda alvo usando o câmbio de cada data
+func (c *Client) ConvertSeries(ctx context.Context, series Series, target string, startDate, endDate time.Time) (Series, error) {
+	target = strings.ToUpper(target)
+	if series.Currency == "" || series.Currency == target {
+		return series, nil
+	}
+
+	fx, err := c.GetFXRates(ctx, series.Currency, target, startDate, endDate)
+	if err != nil {
+		return Series{}, err
+	}
+
+	strategy := c.FXAlign
+	if strategy == "" {
+		strategy = AlignForwardFill
+	}
+	aligned, report := Align(series.Quotes, fx.Quotes, strategy)
+
+	converted := make([]Quote, 0, len(aligned))
+	for _, a := range aligned {
+		converted = append(converted, a.Quote.Scale(a.Other))
+	}
+
+	// Proventos convertidos pelo câmbio da data-com
+	var dividends []Dividend
+	for _, d := range series.Dividends {
+		if rate, ok := ValueOn(fx.Quotes, d.Date); ok {
+			dividends = append(dividends, Dividend{Date: d.Date, Amount: d.Amount * rate})
+		}
+	}
+
+	result := Series{
+		Symbol:        series.Symbol,
+		Currency:      target,
+		Quotes:        converted,
+		Dividends:     dividends,
+		Splits:        series.Splits,
+		QuoteCurrency: series.OriginalCurrency(),
+		Alignment:     &report,
+		Quality:       series.Quality,
+		Stale:         series.Stale,
+		AsOf:          series.AsOf,
+	}
+	result.inheritStaleness(fx)
+	return result, nil
+}
@@ -0,0 +1,293 @@
+package finance
+
+import (
+	"archive/zip"
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CVMProvider lê os informes diários de fundos da CVM (inf_diario_fi_AAAAMM.csv ou .zip,
+// como publicados em dados.cvm.gov.br) de um diretório e expõe cada fundo como "CVM:<CNPJ>".
+// O CNPJ pode vir formatado (00.017.024/0001-53) ou só com dígitos. As cotas já são líquidas de taxas.
+// Classes com subclasses (Resolução 175) são endereçadas como "CVM:<CNPJ>:<ID_SUBCLASSE>".
+type CVMProvider struct {
+	Dir string
+}
+
+// cvmRow é uma cota do informe com a subclasse (vazia no layout antigo e em classes sem subclasses)
+type cvmRow struct {
+	Quote
+	Subclass string
+}
+
+// cvmFilePeriod extrai o mês de referência do nome do arquivo (inf_diario_fi_202301.csv)
+var cvmFilePeriod = regexp.MustCompile(`(\d{6})\.(csv|zip)$`)
+
+// Fetch varre os informes do período e monta a série de cotas do fundo
+func (p *CVMProvider) Fetch(ctx context.Context, c *Client, name string, startDate, endDate time.Time) (Series, error) {
+	subclass := ""
+	if parts := strings.SplitN(name, ":", 2); len(parts) == 2 {
+		name, subclass = parts[0], strings.TrimSpace(parts[1])
+	}
+	cnpj := onlyDigits(name)
+	if len(cnpj) != 14 {
+		return Series{}, fmt.Errorf("CNPJ inválido: %q", name)
+	}
+
+	files, err := p.files(startDate, endDate)
+	if err != nil {
+		return Series{}, err
+	}
+	if len(files) == 0 {
+		return Series{}, fmt.Errorf("nenhum informe diário da CVM em %s para o período", p.Dir)
+	}
+
+	var rows []cvmRow
+	for _, path := range files {
+		if err := ctx.Err(); err != nil {
+			return Series{}, err
+		}
+		found, err := cvmReports.read(path, cnpj)
+		if err != nil {
+			return Series{}, fmt.Errorf("%s: %v", filepath.Base(path), err)
+		}
+		rows = append(rows, found...)
+	}
+	if len(rows) == 0 {
+		return Series{}, fmt.Errorf("fundo %s não encontrado nos informes da CVM", name)
+	}
+	quotes, err := selectSubclass(rows, subclass)
+	if err != nil {
+		return Series{}, fmt.Errorf("fundo %s: %v", name, err)
+	}
+
+	quotes, quality := Clean(quotes, c.Quality)
+	return Series{
+		Currency: "BRL",
+		Quotes:   quotesBetween(quotes, startDate, endDate),
+		Quality:  quality,
+	}, nil
+}
+
+// files lista os informes do diretório, pulando os meses fora do período quando o nome traz AAAAMM
+func (p *CVMProvider) files(startDate, endDate time.Time) ([]string, error) {
+	entries, err := os.ReadDir(p.Dir)
+	if err != nil {
+		return nil, err
+	}
+	from := startDate.Year()*100 + int(startDate.Month())
+	to := endDate.Year()*100 + int(endDate.Month())
+
+	var files []string
+	for _, e := range entries {
+		name := strings.ToLower(e.Name())
+		if e.IsDir() || !(strings.HasSuffix(name, ".csv") || strings.HasSuffix(name, ".zip")) {
+			continue
+		}
+		if m := cvmFilePeriod.FindStringSubmatch(name); m != nil {
+			if month, _ := strconv.Atoi(m[1]); month < from || month > to {
+				continue
+			}
+		}
+		files = append(files, filepath.Join(p.Dir, e.Name()))
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// selectSubclass devolve as cotas da subclasse pedida. Sem subclasse, recusa fundos com cotas de mais
+// de uma subclasse, que se misturariam na mesma série.
+func selectSubclass(rows []cvmRow, subclass string) ([]Quote, error) {
+	var quotes []Quote
+	var found []string
+	for _, row := range rows {
+		label := row.Subclass
+		if label == "" {
+			label = "sem subclasse"
+		}
+		if !containsString(found, label) {
+			found = append(found, label)
+		}
+		if subclass == "" || strings.EqualFold(row.Subclass, subclass) {
+			quotes = append(quotes, row.Quote)
+		}
+	}
+	sort.Strings(found)
+	switch {
+	case subclass == "" && len(found) > 1:
+		return nil, fmt.Errorf("cotas de %d subclasses (%s): use CVM:<CNPJ>:<subclasse>", len(found), strings.Join(found, ", "))
+	case len(quotes) == 0:
+		return nil, fmt.Errorf("subclasse %q não encontrada nos informes (disponíveis: %s)", subclass, strings.Join(found, ", "))
+	}
+	return quotes, nil
+}
+
+// cvmCacheSize limita as entradas de cvmReports; ao passar dele o cache recomeça vazio
+const cvmCacheSize = 1024
+
+// cvmCache guarda as cotas já lidas de cada CNPJ em cada informe mensal, para não varrer de novo
+// arquivos de centenas de MB a cada requisição. Uma entrada vale enquanto o arquivo não mudar.
+type cvmCache struct {
+	mu      sync.Mutex
+	entries map[string]cvmCacheEntry
+}
+
+type cvmCacheEntry struct {
+	modTime time.Time
+	size    int64
+	rows    []cvmRow
+}
+
+// cvmReports é o cache compartilhado pelos clientes (cada requisição cria o seu)
+var cvmReports = &cvmCache{}
+
+// read devolve as cotas do CNPJ no informe, lendo o arquivo só se ele mudou desde a última leitura
+func (c *cvmCache) read(path, cnpj string) ([]cvmRow, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	key := path + "|" + cnpj
+
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	c.mu.Unlock()
+	if ok && entry.modTime.Equal(info.ModTime()) && entry.size == info.Size() {
+		return entry.rows, nil
+	}
+
+	rows, err := readCVMFile(path, cnpj)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	if c.entries == nil || len(c.entries) >= cvmCacheSize {
+		c.entries = make(map[string]cvmCacheEntry)
+	}
+	c.entries[key] = cvmCacheEntry{modTime: info.ModTime(), size: info.Size(), rows: rows}
+	c.mu.Unlock()
+	return rows, nil
+}
+
+// readCVMFile lê um informe (CSV ou ZIP com CSVs dentro) e devolve as cotas do CNPJ
+func readCVMFile(path, cnpj string) ([]cvmRow, error) {
+	if !strings.HasSuffix(strings.ToLower(path), ".zip") {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return parseCVMReport(f, cnpj)
+	}
+
+	archive, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, err
+	}
+	defer archive.Close()
+
+	var rows []cvmRow
+	for _, entry := range archive.File {
+		if !strings.HasSuffix(strings.ToLower(entry.Name), ".csv") {
+			continue
+		}
+		rc, err := entry.Open()
+		if err != nil {
+			return nil, err
+		}
+		found, err := parseCVMReport(rc, cnpj)
+		rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", entry.Name, err)
+		}
+		rows = append(rows, found...)
+	}
+	return rows, nil
+}
+
+// parseCVMReport lê o CSV do informe diário (separador ";", datas AAAA-MM-DD, ponto decimal).
+// Aceita o layout antigo (CNPJ_FUNDO) e o da Resolução 175 (CNPJ_FUNDO_CLASSE, com ID_SUBCLASSE).
+// Os arquivos têm dezenas de milhares de linhas, então só as linhas que contêm o CNPJ são decodificadas.
+func parseCVMReport(r io.Reader, cnpj string) ([]cvmRow, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	if !scanner.Scan() {
+		return nil, scanner.Err()
+	}
+	cnpjCol, dateCol, quotaCol, subclassCol := -1, -1, -1, -1
+	for i, col := range strings.Split(strings.TrimSpace(scanner.Text()), ";") {
+		switch strings.ToUpper(strings.Trim(col, "\ufeff\" ")) {
+		case "CNPJ_FUNDO", "CNPJ_FUNDO_CLASSE":
+			cnpjCol = i
+		case "DT_COMPTC":
+			dateCol = i
+		case "VL_QUOTA":
+			quotaCol = i
+		case "ID_SUBCLASSE":
+			subclassCol = i
+		}
+	}
+	if cnpjCol < 0 || dateCol < 0 || quotaCol < 0 {
+		return nil, fmt.Errorf("cabeçalho fora do padrão inf_diario_fi")
+	}
+
+	// Filtro rápido pelo texto da linha: o CNPJ costuma vir formatado, mas aceita só dígitos
+	formatted := cnpj[:2] + "." + cnpj[2:5] + "." + cnpj[5:8] + "/" + cnpj[8:12] + "-" + cnpj[12:]
+
+	var rows []cvmRow
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.Contains(line, formatted) && !strings.Contains(line, cnpj) {
+			continue
+		}
+		fields := strings.Split(line, ";")
+		if len(fields) <= cnpjCol || len(fields) <= dateCol || len(fields) <= quotaCol {
+			continue
+		}
+		if onlyDigits(fields[cnpjCol]) != cnpj {
+			continue
+		}
+		date, err := time.Parse("2006-01-02", strings.TrimSpace(fields[dateCol]))
+		if err != nil {
+			return nil, fmt.Errorf("data inválida %q", fields[dateCol])
+		}
+		quota, err := parseDecimal(fields[quotaCol], strings.Contains(fields[quotaCol], ","))
+		if err != nil {
+			return nil, fmt.Errorf("cota inválida %q", fields[quotaCol])
+		}
+		row := cvmRow{Quote: Quote{
+			Date:     date,
+			Open:     quota,
+			High:     quota,
+			Low:      quota,
+			Close:    quota,
+			AdjClose: quota,
+		}}
+		if subclassCol >= 0 && subclassCol < len(fields) {
+			row.Subclass = strings.Trim(fields[subclassCol], "\" ")
+		}
+		rows = append(rows, row)
+	}
+	return rows, scanner.Err()
+}
+
+func onlyDigits(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
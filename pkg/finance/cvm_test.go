@@ -0,0 +1,206 @@
+package finance
+
+import (
+	"archive/zip"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	cvmOldHeader = "TP_FUNDO;CNPJ_FUNDO;DT_COMPTC;VL_TOTAL;VL_QUOTA;VL_PATRIM_LIQ;CAPTC_DIA;RESG_DIA;NR_COTST"
+	cvmNewHeader = "TP_FUNDO_CLASSE;CNPJ_FUNDO_CLASSE;ID_SUBCLASSE;DT_COMPTC;VL_TOTAL;VL_QUOTA;VL_PATRIM_LIQ;CAPTC_DIA;RESG_DIA;NR_COTST"
+	cvmCNPJ      = "00017024000153"
+)
+
+func TestParseCVMReport(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    string // Série no formato de closesOf
+		sub     string // Subclasses das cotas, na ordem
+		wantErr string
+	}{
+		{"layout antigo com CNPJ formatado", cvmOldHeader + "\n" +
+			"FI;00.017.024/0001-53;2024-01-02;100;1.5;100;0;0;10\n" +
+			"FI;11.111.111/0001-11;2024-01-02;100;9.9;100;0;0;10\n" +
+			"FI;00.017.024/0001-53;2024-01-03;100;1.6;100;0;0;10\n",
+			"[2:2 3:2]", ",", ""},
+		{"layout antigo com CNPJ só com dígitos", cvmOldHeader + "\n" +
+			"FI;00017024000153;2024-01-02;100;3.25;100;0;0;10\n",
+			"[2:3]", "", ""},
+		{"Resolução 175 com subclasses", "\ufeff" + cvmNewHeader + "\n" +
+			"Classes - FIF;00.017.024/0001-53;SUB-A;2024-01-02;100;10;100;0;0;10\n" +
+			"Classes - FIF;00.017.024/0001-53;SUB-B;2024-01-02;100;20;100;0;0;10\n" +
+			"Classes - FIF;00.017.024/0001-53;;2024-01-03;100;30;100;0;0;10\n",
+			"[2:10 2:20 3:30]", "SUB-A,SUB-B,", ""},
+		{"CNPJ em outra coluna não conta", cvmOldHeader + "\n" +
+			"FI;11.111.111/0001-11;2024-01-02;00017024000153;1;100;0;0;10\n",
+			"[]", "", ""},
+		{"cota com vírgula decimal", cvmOldHeader + "\n" +
+			"FI;00.017.024/0001-53;2024-01-02;100;12,5;100;0;0;10\n",
+			"[2:12]", "", ""},
+		{"cabeçalho fora do padrão", "CNPJ;DATA;COTA\n", "", "", "cabeçalho fora do padrão"},
+		{"data inválida", cvmOldHeader + "\nFI;00.017.024/0001-53;02/01/2024;100;1;100;0;0;10\n", "", "", `data inválida "02/01/2024"`},
+		{"cota inválida", cvmOldHeader + "\nFI;00.017.024/0001-53;2024-01-02;100;x;100;0;0;10\n", "", "", `cota inválida "x"`},
+		{"vazio", "", "[]", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := parseCVMReport(strings.NewReader(tt.input), cvmCNPJ)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("erro %v, esperado contendo %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("erro inesperado: %v", err)
+			}
+			quotes := make([]Quote, len(rows))
+			subs := make([]string, len(rows))
+			for i, row := range rows {
+				quotes[i], subs[i] = row.Quote, row.Subclass
+			}
+			if got := closesOf(quotes); got != tt.want {
+				t.Errorf("série %s, esperado %s", got, tt.want)
+			}
+			if got := strings.Join(subs, ","); got != tt.sub {
+				t.Errorf("subclasses %q, esperado %q", got, tt.sub)
+			}
+		})
+	}
+}
+
+func TestCVMFilesByMonth(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{
+		"inf_diario_fi_202312.csv",
+		"inf_diario_fi_202401.zip",
+		"INF_DIARIO_FI_202402.CSV",
+		"inf_diario_fi_202403.csv",
+		"extra.csv", // Sem mês no nome: sempre lido
+		"leia-me.txt",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, "inf_diario_fi_202401.csv"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	p := &CVMProvider{Dir: dir}
+	files, err := p.files(day(15), time.Date(2024, time.February, 10, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, f := range files {
+		got = append(got, filepath.Base(f))
+	}
+	if want := "[INF_DIARIO_FI_202402.CSV extra.csv inf_diario_fi_202401.zip]"; fmt.Sprint(got) != want {
+		t.Errorf("informes %v, esperado %s", got, want)
+	}
+}
+
+// writeCVMZip grava um informe compactado como os publicados pela CVM
+func writeCVMZip(t *testing.T, path, name, content string) {
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	w := zip.NewWriter(f)
+	entry, err := w.Create(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry.Write([]byte(content))
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCVMProviderSubclasses(t *testing.T) {
+	dir := t.TempDir()
+	writeCVMZip(t, filepath.Join(dir, "inf_diario_fi_202401.zip"), "inf_diario_fi_202401.csv", cvmNewHeader+"\n"+
+		"Classes - FIF;00.017.024/0001-53;SUB-A;2024-01-02;100;10;100;0;0;10\n"+
+		"Classes - FIF;00.017.024/0001-53;SUB-B;2024-01-02;100;20;100;0;0;10\n"+
+		"Classes - FIF;00.017.024/0001-53;SUB-A;2024-01-03;100;11;100;0;0;10\n"+
+		"Classes - FIF;00.017.024/0001-53;SUB-B;2024-01-03;100;21;100;0;0;10\n")
+
+	c := NewClient()
+	p := &CVMProvider{Dir: dir}
+	from, to := day(1), day(31)
+	tests := []struct {
+		name    string
+		want    string
+		wantErr string
+	}{
+		{"00.017.024/0001-53", "", "2 subclasses (SUB-A, SUB-B)"},
+		{"00.017.024/0001-53:SUB-B", "[2:20 3:21]", ""},
+		{"00017024000153:sub-a", "[2:10 3:11]", ""},
+		{"00017024000153:SUB-C", "", `subclasse "SUB-C" não encontrada`},
+		{"11.111.111/0001-11", "", "não encontrado"},
+		{"123", "", "CNPJ inválido"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			series, err := p.Fetch(context.Background(), c, tt.name, from, to)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("erro %v, esperado contendo %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("erro inesperado: %v", err)
+			}
+			if got := closesOf(series.Quotes); got != tt.want || series.Currency != "BRL" {
+				t.Errorf("série %s em %s, esperado %s em BRL", got, series.Currency, tt.want)
+			}
+		})
+	}
+}
+
+func TestCVMReportsAreCached(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "inf_diario_fi_202401.csv")
+	write := func(quota string, mtime time.Time) {
+		content := cvmOldHeader + "\nFI;00.017.024/0001-53;2024-01-02;100;" + quota + ";100;0;0;10\n"
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	read := func() string {
+		series, err := (&CVMProvider{Dir: dir}).Fetch(context.Background(), NewClient(), cvmCNPJ, day(1), day(31))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return closesOf(series.Quotes)
+	}
+	mtime := time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC)
+
+	write("10", mtime)
+	if got := read(); got != "[2:10]" {
+		t.Fatalf("primeira leitura %s, esperado [2:10]", got)
+	}
+	// Mesmo tamanho e mesma data de modificação: a cota vem do cache, mesmo de outro provedor
+	write("20", mtime)
+	if got := read(); got != "[2:10]" {
+		t.Errorf("arquivo inalterado: %s, esperado [2:10] do cache", got)
+	}
+	// Arquivo republicado: lê de novo
+	write("30", mtime.Add(time.Hour))
+	if got := read(); got != "[2:30]" {
+		t.Errorf("arquivo atualizado: %s, esperado [2:30]", got)
+	}
+}
@@ -0,0 +1,89 @@
+package finance
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Custos padrão de um ETF alavancado típico (ex: QLD/SSO), em fração ao ano
+const (
+	DefaultLeveragedExpenseRatio = 0.0095
+	DefaultLeveragedBorrowRate   = 0.05
+)
+
+// leveragedFloor evita preço zero (ou negativo) se o ativo cair mais de 1/L num dia:
+// o fundo é praticamente zerado, mas a série continua utilizável pelos cálculos
+const leveragedFloor = 1e-6
+
+// LeveragedProvider simula ETFs alavancados e inversos com reset diário: "LEV:2:^IXIC", "LEV:-1:^GSPC".
+// Cada pregão rende L vezes o retorno total do ativo, menos a taxa de administração e o custo de
+// financiamento da parte tomada (L-1 nos alavancados, |L| nos inversos), proporcionais aos dias corridos.
+type LeveragedProvider struct {
+	ExpenseRatio float64 // Taxa de administração ao ano (0.0095 = 0,95%)
+	BorrowRate   float64 // Custo de financiamento/aluguel ao ano
+}
+
+// Fetch busca o ativo subjacente e compõe a série alavancada (base 100)
+func (p *LeveragedProvider) Fetch(ctx context.Context, c *Client, name string, startDate, endDate time.Time) (Series, error) {
+	parts := strings.SplitN(name, ":", 2)
+	if len(parts) != 2 {
+		return Series{}, fmt.Errorf("símbolo alavancado inválido %q (use LEV:<fator>:<ativo>)", name)
+	}
+	leverage, err := strconv.ParseFloat(parts[0], 64)
+	if err != nil || leverage == 0 {
+		return Series{}, fmt.Errorf("fator de alavancagem inválido %q", parts[0])
+	}
+
+	underlying, err := c.GetSeries(ctx, parts[1], startDate, endDate)
+	if err != nil {
+		return Series{}, fmt.Errorf("ativo %s: %v", parts[1], err)
+	}
+	if len(underlying.Quotes) == 0 {
+		return Series{}, fmt.Errorf("ativo %s sem dados no período", parts[1])
+	}
+
+	borrowed := leverage - 1
+	if leverage < 0 {
+		borrowed = -leverage
+	}
+	if borrowed < 0 {
+		borrowed = 0
+	}
+	annualCost := p.ExpenseRatio + borrowed*p.BorrowRate
+
+	result := Series{Currency: underlying.Currency}
+	result.inheritStaleness(underlying)
+
+	value := 100.0
+	for i, q := range underlying.Quotes {
+		if i > 0 {
+			prev := underlying.Quotes[i-1]
+			days := q.Date.Sub(prev.Date).Hours() / 24
+			change := leverage*(totalReturn(q)/totalReturn(prev)-1) - annualCost*days/365
+			value *= 1 + change
+			if value < leveragedFloor {
+				value = leveragedFloor
+			}
+		}
+		result.Quotes = append(result.Quotes, Quote{
+			Date:     q.Date,
+			Open:     value,
+			High:     value,
+			Low:      value,
+			Close:    value,
+			AdjClose: value,
+		})
+	}
+	return result, nil
+}
+
+// totalReturn devolve o fechamento ajustado por proventos, se disponível
+func totalReturn(q Quote) float64 {
+	if q.AdjClose > 0 {
+		return q.AdjClose
+	}
+	return q.Close
+}
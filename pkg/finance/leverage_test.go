@@ -0,0 +1,118 @@
+package finance
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestLeveragedDailyReset(t *testing.T) {
+	// Sobe 10% e cai 10%: o ativo termina em 99, mas cada fator compõe o retorno do dia
+	c := basketClient(map[string][]Quote{
+		"x": dayQuotes(map[int]float64{2: 100, 3: 110, 4: 99}, 2, 3, 4),
+	})
+	p := &LeveragedProvider{}
+	tests := []struct {
+		leverage string
+		want     string
+	}{
+		{"1", "2:100.00 3:110.00 4:99.00"},
+		{"2", "2:100.00 3:120.00 4:96.00"},   // 1,2 × 0,8
+		{"3", "2:100.00 3:130.00 4:91.00"},   // 1,3 × 0,7
+		{"-1", "2:100.00 3:90.00 4:99.00"},   // 0,9 × 1,1
+		{"-2", "2:100.00 3:80.00 4:96.00"},   // 0,8 × 1,2
+		{"0.5", "2:100.00 3:105.00 4:99.75"}, // 1,05 × 0,95
+	}
+	for _, tt := range tests {
+		series, err := p.Fetch(context.Background(), c, tt.leverage+":T:x", day(1), day(31))
+		if err != nil {
+			t.Fatalf("%sx: %v", tt.leverage, err)
+		}
+		if got := valuesOf(series.Quotes); got != tt.want {
+			t.Errorf("%sx: série %s, esperado %s", tt.leverage, got, tt.want)
+		}
+		if series.Currency != "BRL" {
+			t.Errorf("%sx: moeda %s, esperado a do ativo (BRL)", tt.leverage, series.Currency)
+		}
+	}
+}
+
+func TestLeveragedCosts(t *testing.T) {
+	// Preço parado de sexta (5/jan/2024) a segunda (8/jan): três dias corridos de custo num só pregão
+	c := basketClient(map[string][]Quote{
+		"x": dayQuotes(map[int]float64{4: 100, 5: 100, 8: 100}, 4, 5, 8),
+	})
+	p := &LeveragedProvider{ExpenseRatio: 0.0365, BorrowRate: 0.073} // 0,01% e 0,02% ao dia corrido
+	tests := []struct {
+		leverage string
+		daily    float64 // Custo por dia corrido
+	}{
+		{"1", 0.0001},          // Só a taxa de administração
+		{"0.5", 0.0001},        // Nada tomado emprestado
+		{"2", 0.0001 + 0.0002}, // Toma 1x
+		{"3", 0.0001 + 0.0004}, // Toma 2x
+		{"-1", 0.0001 + 0.0002},
+		{"-3", 0.0001 + 0.0006}, // Aluga 3x
+	}
+	for _, tt := range tests {
+		series, err := p.Fetch(context.Background(), c, tt.leverage+":T:x", day(1), day(31))
+		if err != nil {
+			t.Fatalf("%sx: %v", tt.leverage, err)
+		}
+		friday := 100 * (1 - tt.daily)
+		monday := friday * (1 - 3*tt.daily)
+		if got := series.Quotes; math.Abs(got[1].Close-friday) > 1e-9 || math.Abs(got[2].Close-monday) > 1e-9 {
+			t.Errorf("%sx: %.6f e %.6f, esperado %.6f e %.6f", tt.leverage, got[1].Close, got[2].Close, friday, monday)
+		}
+	}
+}
+
+func TestLeveragedUsesTotalReturnAndFloor(t *testing.T) {
+	c := basketClient(map[string][]Quote{
+		// Fechamento parado, mas o ajustado sobe 5% (provento)
+		"div": {{Date: day(2), Close: 100, AdjClose: 95}, {Date: day(3), Close: 100, AdjClose: 99.75}},
+		// Queda de 50% num dia: o 3x perderia 150%
+		"crash": dayQuotes(map[int]float64{2: 100, 3: 50, 4: 100}, 2, 3, 4),
+	})
+	p := &LeveragedProvider{}
+
+	series, err := p.Fetch(context.Background(), c, "2:T:div", day(1), day(31))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := series.Quotes[1].Close; math.Abs(got-110) > 1e-9 {
+		t.Errorf("2x com provento: %.4f, esperado 110", got)
+	}
+
+	series, err = p.Fetch(context.Background(), c, "3:T:crash", day(1), day(31))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := series.Quotes[1].Close; got != leveragedFloor {
+		t.Errorf("3x após queda de 50%%: %g, esperado o piso %g", got, leveragedFloor)
+	}
+	if got := series.Quotes[2].Close; got <= 0 || got > 5*leveragedFloor {
+		t.Errorf("3x após a recuperação: %g, esperado positivo e perto do piso", got)
+	}
+}
+
+func TestLeveragedErrors(t *testing.T) {
+	c := basketClient(map[string][]Quote{"x": dayQuotes(map[int]float64{2: 100}, 2)})
+	p := &LeveragedProvider{}
+	tests := []struct {
+		name    string
+		wantErr string
+	}{
+		{"T:x", "fator de alavancagem inválido"},
+		{"2", "use LEV:<fator>:<ativo>"},
+		{"0:T:x", "fator de alavancagem inválido"},
+		{"dois:T:x", "fator de alavancagem inválido"},
+		{"2:T:none", "ativo T:none"},
+	}
+	for _, tt := range tests {
+		if _, err := p.Fetch(context.Background(), c, tt.name, day(1), day(31)); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s: erro %v, esperado contendo %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
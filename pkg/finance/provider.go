@@ -0,0 +1,80 @@
+package finance
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Provider fornece séries para símbolos com prefixo próprio, como "FILE:meu_fundo".
+// Recebe o nome sem o prefixo e o cliente, para poder buscar outras séries (câmbio, componentes).
+type Provider interface {
+	Fetch(ctx context.Context, c *Client, name string, startDate, endDate time.Time) (Series, error)
+}
+
+// RegisterProvider associa um provedor a um prefixo (sem os dois-pontos)
+func (c *Client) RegisterProvider(prefix string, p Provider) {
+	if c.Providers == nil {
+		c.Providers = make(map[string]Provider)
+	}
+	c.Providers[strings.ToUpper(prefix)] = p
+}
+
+// NewLocalClient cria um cliente com o cache local e os provedores de símbolos com prefixo, lendo os
+// dados de dataDir: FILE: (prices), CVM: (cvm), BASKET: e BASKET-BH: (baskets.json) e LEV: com os
+// custos padrão. cache permite compartilhar o mesmo cache entre clientes; nil usa dataDir/cache.
+func NewLocalClient(dataDir string, cache *FileStore) *Client {
+	if cache == nil {
+		cache = NewFileStore(filepath.Join(dataDir, "cache"))
+	}
+	baskets := filepath.Join(dataDir, "baskets.json")
+
+	c := NewClient()
+	c.Cache = cache
+	c.RegisterProvider("FILE", &FileProvider{Dir: filepath.Join(dataDir, "prices")})
+	c.RegisterProvider("CVM", &CVMProvider{Dir: filepath.Join(dataDir, "cvm")})
+	c.RegisterProvider("BASKET", &BasketProvider{Rebalance: RebalanceDaily, File: baskets})
+	c.RegisterProvider("BASKET-BH", &BasketProvider{Rebalance: BuyAndHold, File: baskets})
+	c.RegisterProvider("LEV", &LeveragedProvider{
+		ExpenseRatio: DefaultLeveragedExpenseRatio,
+		BorrowRate:   DefaultLeveragedBorrowRate,
+	})
+	return c
+}
+
+// providerFor devolve o provedor e o nome sem prefixo, se o símbolo usar um prefixo registrado
+func (c *Client) providerFor(symbol string) (Provider, string, bool) {
+	i := strings.Index(symbol, ":")
+	if i <= 0 {
+		return nil, "", false
+	}
+	p, ok := c.Providers[strings.ToUpper(symbol[:i])]
+	return p, symbol[i+1:], ok
+}
+
+// StaticProvider serve séries já carregadas em memória (ex: upload para uma única simulação)
+type StaticProvider map[string]Series
+
+// Fetch devolve a série pelo nome, recortada no período
+func (p StaticProvider) Fetch(ctx context.Context, c *Client, name string, startDate, endDate time.Time) (Series, error) {
+	series, ok := p[name]
+	if !ok {
+		return Series{}, fmt.Errorf("série %q não encontrada", name)
+	}
+	series.Quotes = quotesBetween(series.Quotes, startDate, endDate)
+	return series, nil
+}
+
+// quotesBetween recorta as cotações no período (datas inclusivas)
+func quotesBetween(quotes []Quote, startDate, endDate time.Time) []Quote {
+	from, to := DateKey(startDate), DateKey(endDate)
+	var out []Quote
+	for _, q := range quotes {
+		if day := DateKey(q.Date); day >= from && day <= to {
+			out = append(out, q)
+		}
+	}
+	return out
+}
@@ -0,0 +1,192 @@
+package finance
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// RepairAction define o que fazer com um ponto problemático da série
+type RepairAction string
+
+const (
+	RepairKeep RepairAction = "keep"  // Mantém o ponto e só registra no relatório
+	RepairDrop RepairAction = "drop"  // Remove o ponto da série
+	RepairFill RepairAction = "ffill" // Substitui pelo último preço válido
+)
+
+// QualityRules configura a verificação e o reparo das séries baixadas
+type QualityRules struct {
+	Invalid    RepairAction // Preços nulos, zerados ou negativos (keep equivale a drop)
+	Jumps      RepairAction // Picos isolados que revertem no dia seguinte (provável tick errado)
+	MaxJump    float64      // Variação diária (fração) considerada suspeita. 0 desativa
+	MaxGapDays int          // Intervalo em dias corridos registrado como lacuna. 0 desativa
+}
+
+// DefaultQualityRules mantém o comportamento histórico (descartar preços inválidos)
+// e apenas sinaliza picos e lacunas.
+func DefaultQualityRules() QualityRules {
+	return QualityRules{
+		Invalid:    RepairDrop,
+		Jumps:      RepairKeep,
+		MaxJump:    0.5,
+		MaxGapDays: 7,
+	}
+}
+
+// QualityIssue é um problema encontrado em uma data da série
+type QualityIssue struct {
+	Date   time.Time
+	Kind   string // null, non-positive, duplicate, jump, gap
+	Detail string
+}
+
+// maxQualityIssues limita o detalhamento guardado no relatório
+const maxQualityIssues = 20
+
+// QualityReport resume a verificação de qualidade de uma série
+type QualityReport struct {
+	Points       int // Pontos recebidos do provedor
+	Nulls        int // Fechamentos nulos
+	PartialNulls int // Pontos com abertura/máxima/mínima/volume nulos e fechamento válido
+	NonPositive  int // Fechamentos zerados ou negativos
+	Duplicates   int // Datas repetidas (mantido o último registro)
+	Jumps        int // Picos isolados acima de MaxJump
+	Gaps         int // Intervalos maiores que MaxGapDays
+	Repaired     int // Pontos corrigidos por forward-fill
+	Dropped      int // Pontos removidos
+	Issues       []QualityIssue
+}
+
+// HasIssues indica se algo relevante foi encontrado
+func (r *QualityReport) HasIssues() bool {
+	return r.Nulls+r.NonPositive+r.Duplicates+r.Jumps+r.Gaps > 0
+}
+
+// String descreve o relatório para exibição ao usuário
+func (r *QualityReport) String() string {
+	var parts []string
+	add := func(n int, label string) {
+		if n > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", n, label))
+		}
+	}
+	add(r.Nulls, "nulos")
+	add(r.NonPositive, "zerados/negativos")
+	add(r.Duplicates, "duplicados")
+	add(r.Jumps, "picos suspeitos")
+	add(r.Gaps, "lacunas")
+	add(r.Repaired, "corrigidos")
+	add(r.Dropped, "removidos")
+	if len(parts) == 0 {
+		return "sem problemas"
+	}
+	return strings.Join(parts, ", ")
+}
+
+func (r *QualityReport) add(date time.Time, kind, detail string) {
+	if len(r.Issues) < maxQualityIssues {
+		r.Issues = append(r.Issues, QualityIssue{Date: date, Kind: kind, Detail: detail})
+	}
+}
+
+// Clean verifica e repara uma série conforme as regras. Fechamentos nulos devem
+// chegar como NaN para serem distinguidos de preços zerados.
+func Clean(quotes []Quote, rules QualityRules) ([]Quote, *QualityReport) {
+	report := &QualityReport{Points: len(quotes)}
+
+	// 1. Ordem cronológica e datas duplicadas (mantém o último registro do dia)
+	sorted := make([]Quote, len(quotes))
+	copy(sorted, quotes)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Date.Before(sorted[j].Date) })
+
+	deduped := make([]Quote, 0, len(sorted))
+	for _, q := range sorted {
+		if n := len(deduped); n > 0 && DateKey(deduped[n-1].Date) == DateKey(q.Date) {
+			report.Duplicates++
+			report.Dropped++
+			report.add(q.Date, "duplicate", "data repetida")
+			deduped[n-1] = q
+			continue
+		}
+		deduped = append(deduped, q)
+	}
+
+	// 2. Preços nulos, zerados ou negativos
+	valid := make([]Quote, 0, len(deduped))
+	for _, q := range deduped {
+		if q.Close > 0 {
+			valid = append(valid, q)
+			continue
+		}
+
+		if math.IsNaN(q.Close) {
+			report.Nulls++
+			report.add(q.Date, "null", "fechamento nulo")
+		} else {
+			report.NonPositive++
+			report.add(q.Date, "non-positive", fmt.Sprintf("fechamento %.4f", q.Close))
+		}
+
+		if rules.Invalid == RepairFill && len(valid) > 0 {
+			valid = append(valid, repairedFrom(valid[len(valid)-1], q.Date))
+			report.Repaired++
+			continue
+		}
+		report.Dropped++
+	}
+
+	// 3. Picos isolados: sobe (ou cai) além do limite e volta no dia seguinte
+	cleaned := valid
+	if rules.MaxJump > 0 && len(valid) > 2 {
+		limit := math.Log(1 + rules.MaxJump)
+		cleaned = make([]Quote, 0, len(valid))
+		for i, q := range valid {
+			if i == 0 || i == len(valid)-1 {
+				cleaned = append(cleaned, q)
+				continue
+			}
+			in := math.Log(q.Close / valid[i-1].Close)
+			out := math.Log(valid[i+1].Close / q.Close)
+			if math.Abs(in) <= limit || math.Abs(out) <= limit || (in > 0) == (out > 0) {
+				cleaned = append(cleaned, q)
+				continue
+			}
+
+			report.Jumps++
+			report.add(q.Date, "jump", fmt.Sprintf("variação de %+.1f%% revertida no dia seguinte", (math.Exp(in)-1)*100))
+			switch rules.Jumps {
+			case RepairDrop:
+				report.Dropped++
+			case RepairFill:
+				cleaned = append(cleaned, repairedFrom(cleaned[len(cleaned)-1], q.Date))
+				report.Repaired++
+			default:
+				cleaned = append(cleaned, q)
+			}
+		}
+	}
+
+	// 4. Lacunas (apenas registradas)
+	if rules.MaxGapDays > 0 {
+		for i := 1; i < len(cleaned); i++ {
+			days := int(cleaned[i].Date.Sub(cleaned[i-1].Date).Hours() / 24)
+			if days > rules.MaxGapDays {
+				report.Gaps++
+				report.add(cleaned[i].Date, "gap", fmt.Sprintf("%d dias sem cotação", days))
+			}
+		}
+	}
+
+	return cleaned, report
+}
+
+// repairedFrom repete o último preço válido em uma nova data
+func repairedFrom(prev Quote, date time.Time) Quote {
+	prev.Date = date
+	prev.Open, prev.High, prev.Low = prev.Close, prev.Close, prev.Close
+	prev.Volume = 0
+	return prev
+}
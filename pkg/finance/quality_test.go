@@ -0,0 +1,146 @@
+package finance
+
+import (
+	"fmt"
+	"math"
+	"testing"
+	"time"
+)
+
+// closesOf resume a série como "dia:fechamento" para comparar nos testes
+func closesOf(quotes []Quote) string {
+	var parts []string
+	for _, q := range quotes {
+		parts = append(parts, fmt.Sprintf("%d:%.0f", q.Date.Day(), q.Close))
+	}
+	return fmt.Sprint(parts)
+}
+
+func TestCleanInvalidPrices(t *testing.T) {
+	quotes := []Quote{
+		{Date: day(2), Close: 10},
+		{Date: day(3), Close: math.NaN()},
+		{Date: day(4), Close: 0},
+		{Date: day(5), Close: -1},
+		{Date: day(8), Close: 11},
+	}
+	tests := []struct {
+		name     string
+		action   RepairAction
+		want     string
+		repaired int
+		dropped  int
+	}{
+		{"drop", RepairDrop, "[2:10 8:11]", 0, 3},
+		{"keep equivale a drop", RepairKeep, "[2:10 8:11]", 0, 3},
+		{"ffill", RepairFill, "[2:10 3:10 4:10 5:10 8:11]", 3, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cleaned, report := Clean(quotes, QualityRules{Invalid: tt.action})
+			if got := closesOf(cleaned); got != tt.want {
+				t.Errorf("série %s, esperado %s", got, tt.want)
+			}
+			if report.Nulls != 1 || report.NonPositive != 2 || report.Repaired != tt.repaired || report.Dropped != tt.dropped {
+				t.Errorf("relatório %+v: esperado 1 nulo, 2 zerados/negativos, %d corrigidos e %d removidos", report, tt.repaired, tt.dropped)
+			}
+			if !report.HasIssues() {
+				t.Error("HasIssues = false com preços inválidos")
+			}
+		})
+	}
+
+	// Sem preço válido anterior, o forward-fill não tem o que repetir e descarta
+	cleaned, report := Clean([]Quote{{Date: day(2), Close: 0}, {Date: day(3), Close: 10}}, QualityRules{Invalid: RepairFill})
+	if closesOf(cleaned) != "[3:10]" || report.Dropped != 1 {
+		t.Errorf("primeiro ponto inválido: série %s, %d removidos; esperado [3:10] e 1", closesOf(cleaned), report.Dropped)
+	}
+}
+
+func TestCleanDuplicatesAndOrder(t *testing.T) {
+	// Fora de ordem e com o dia 3 repetido: vale o último registro recebido do dia
+	quotes := []Quote{
+		{Date: day(4), Close: 12},
+		{Date: day(3), Close: 10},
+		{Date: day(2), Close: 9},
+		{Date: day(3).Add(16 * time.Hour), Close: 11},
+	}
+	cleaned, report := Clean(quotes, DefaultQualityRules())
+	if got := closesOf(cleaned); got != "[2:9 3:11 4:12]" {
+		t.Errorf("série %s, esperado [2:9 3:11 4:12]", got)
+	}
+	if report.Points != 4 || report.Duplicates != 1 || report.Dropped != 1 {
+		t.Errorf("relatório %+v: esperado 4 pontos, 1 duplicado e 1 removido", report)
+	}
+	if len(report.Issues) != 1 || report.Issues[0].Kind != "duplicate" {
+		t.Errorf("ocorrências %+v, esperado uma duplicate", report.Issues)
+	}
+}
+
+func TestCleanJumps(t *testing.T) {
+	// Pico isolado no dia 4 (100 -> 200 -> 100); no dia 9 o preço dobra e fica, sem reverter
+	quotes := []Quote{
+		{Date: day(2), Close: 100},
+		{Date: day(3), Close: 100},
+		{Date: day(4), Close: 200},
+		{Date: day(5), Close: 100},
+		{Date: day(8), Close: 100},
+		{Date: day(9), Close: 200},
+		{Date: day(10), Close: 200},
+	}
+	tests := []struct {
+		name     string
+		action   RepairAction
+		want     string
+		repaired int
+		dropped  int
+	}{
+		{"keep", RepairKeep, "[2:100 3:100 4:200 5:100 8:100 9:200 10:200]", 0, 0},
+		{"drop", RepairDrop, "[2:100 3:100 5:100 8:100 9:200 10:200]", 0, 1},
+		{"ffill", RepairFill, "[2:100 3:100 4:100 5:100 8:100 9:200 10:200]", 1, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cleaned, report := Clean(quotes, QualityRules{Jumps: tt.action, MaxJump: 0.5})
+			if got := closesOf(cleaned); got != tt.want {
+				t.Errorf("série %s, esperado %s", got, tt.want)
+			}
+			if report.Jumps != 1 || report.Repaired != tt.repaired || report.Dropped != tt.dropped {
+				t.Errorf("relatório %+v: esperado 1 pico, %d corrigidos e %d removidos", report, tt.repaired, tt.dropped)
+			}
+			if len(report.Issues) != 1 || report.Issues[0].Kind != "jump" || report.Issues[0].Date.Day() != 4 {
+				t.Errorf("ocorrências %+v, esperado um jump no dia 4", report.Issues)
+			}
+		})
+	}
+
+	// Abaixo do limite, ou com MaxJump zerado, nada é sinalizado
+	for _, maxJump := range []float64{1.5, 0} {
+		if _, report := Clean(quotes, QualityRules{Jumps: RepairDrop, MaxJump: maxJump}); report.Jumps != 0 {
+			t.Errorf("MaxJump %.1f: %d picos, esperado 0", maxJump, report.Jumps)
+		}
+	}
+}
+
+func TestCleanGaps(t *testing.T) {
+	quotes := []Quote{
+		{Date: day(2), Close: 10},
+		{Date: day(5), Close: 10},
+		{Date: day(15), Close: 10}, // 10 dias sem cotação
+		{Date: day(22), Close: 10}, // 7 dias: no limite, não é lacuna
+	}
+	cleaned, report := Clean(quotes, DefaultQualityRules())
+	if len(cleaned) != 4 {
+		t.Errorf("%d pontos, esperado 4: lacunas são só registradas", len(cleaned))
+	}
+	if report.Gaps != 1 || len(report.Issues) != 1 || report.Issues[0].Date.Day() != 15 {
+		t.Errorf("relatório %+v, esperado uma lacuna no dia 15", report)
+	}
+	if report.String() != "1 lacunas" {
+		t.Errorf("String = %q", report.String())
+	}
+
+	if _, report := Clean(quotes, QualityRules{Invalid: RepairDrop}); report.Gaps != 0 || report.HasIssues() {
+		t.Errorf("MaxGapDays zerado: relatório %+v, esperado sem problemas", report)
+	}
+}
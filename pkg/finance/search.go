@@ -0,0 +1,119 @@
+package finance
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// SymbolMatch é um resultado da busca de símbolos no provedor
+type SymbolMatch struct {
+	Symbol   string `json:"symbol"`
+	Name     string `json:"name"`
+	Exchange string `json:"exchange,omitempty"`
+	Type     string `json:"type,omitempty"` // EQUITY, ETF, CRYPTOCURRENCY, INDEX...
+}
+
+// searchResponse é o formato da busca do Yahoo (v1/finance/search)
+type searchResponse struct {
+	Quotes []struct {
+		Symbol    string `json:"symbol"`
+		ShortName string `json:"shortname"`
+		LongName  string `json:"longname"`
+		Exchange  string `json:"exchDisp"`
+		QuoteType string `json:"quoteType"`
+	} `json:"quotes"`
+}
+
+// SearchSymbols busca símbolos pelo nome ou ticker no provedor
+func (c *Client) SearchSymbols(ctx context.Context, query string) ([]SymbolMatch, error) {
+	if c.SearchURL == "" {
+		return nil, nil
+	}
+	transport := c.Transport
+	if transport == nil {
+		transport = DefaultTransport
+	}
+
+	body, err := transport.Get(ctx, fmt.Sprintf("%s?q=%s&quotesCount=10&newsCount=0", c.SearchURL, url.QueryEscape(query)))
+	if err != nil {
+		return nil, err
+	}
+	var resp searchResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, err
+	}
+
+	matches := make([]SymbolMatch, 0, len(resp.Quotes))
+	for _, q := range resp.Quotes {
+		name := q.LongName
+		if name == "" {
+			name = q.ShortName
+		}
+		matches = append(matches, SymbolMatch{Symbol: q.Symbol, Name: name, Exchange: q.Exchange, Type: q.QuoteType})
+	}
+	return matches, nil
+}
+
+// b3Ticker reconhece tickers da B3 digitados sem o sufixo .SA (PETR4, BOVA11, TAEE11, PETR4F)
+var b3Ticker = regexp.MustCompile(`^[A-Z]{4}\d{1,2}F?$`)
+
+// SuggestSymbols devolve grafias alternativas de um símbolo que pode ter sido digitado errado,
+// como ações brasileiras sem o sufixo .SA ("PETR4" -> "PETR4.SA") ou do mercado fracionário.
+func SuggestSymbols(symbol string) []string {
+	s := strings.ToUpper(strings.TrimSpace(symbol))
+	var suggestions []string
+	if s != symbol {
+		suggestions = append(suggestions, s)
+	}
+	if b3Ticker.MatchString(s) {
+		suggestions = append(suggestions, strings.TrimSuffix(s, "F")+".SA")
+	}
+	return suggestions
+}
+
+// SymbolCheck é o resultado da validação de um símbolo no período
+type SymbolCheck struct {
+	Symbol      string   `json:"symbol"`
+	Valid       bool     `json:"valid"`
+	Points      int      `json:"points"`
+	First       string   `json:"first,omitempty"` // Primeiro pregão com dados no período
+	Last        string   `json:"last,omitempty"`
+	Currency    string   `json:"currency,omitempty"`
+	Error       string   `json:"error,omitempty"`
+	Suggestions []string `json:"suggestions,omitempty"` // Alternativas que têm dados no período
+}
+
+// CheckSymbol verifica se o símbolo existe e tem cotações no período.
+// Quando não tem, testa as grafias de SuggestSymbols e devolve as que funcionam.
+func (c *Client) CheckSymbol(ctx context.Context, symbol string, startDate, endDate time.Time) SymbolCheck {
+	check := SymbolCheck{Symbol: symbol}
+	series, err := c.GetSeries(ctx, symbol, startDate, endDate)
+	switch {
+	case err != nil:
+		check.Error = err.Error()
+	case len(series.Quotes) == 0:
+		check.Error = "sem dados no período"
+	default:
+		check.Valid = true
+		check.Points = len(series.Quotes)
+		check.First = DateKey(series.Quotes[0].Date)
+		check.Last = DateKey(series.Quotes[len(series.Quotes)-1].Date)
+		check.Currency = series.Currency
+		return check
+	}
+
+	for _, alt := range SuggestSymbols(symbol) {
+		if ctx.Err() != nil {
+			break
+		}
+		if s, err := c.GetSeries(ctx, alt, startDate, endDate); err == nil && len(s.Quotes) > 0 {
+			check.Suggestions = append(check.Suggestions, alt)
+		}
+	}
+	return check
+}
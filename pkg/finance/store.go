@@ -0,0 +1,215 @@
+package finance
+
+import (
+	"encoding/json"
+	"math"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// FileStore guarda a última série conhecida de cada símbolo em disco (um JSON por símbolo),
+// usada como fallback quando o provedor está fora do ar.
+type FileStore struct {
+	Dir string
+	mu  sync.Mutex
+}
+
+// storedSeries é o formato gravado em disco
+type storedSeries struct {
+	Symbol    string
+	Currency  string
+	FetchedAt time.Time
+	Quotes    []Quote
+	Dividends []Dividend
+	Splits    []Split
+}
+
+// NewFileStore cria o armazenamento no diretório informado
+func NewFileStore(dir string) *FileStore {
+	return &FileStore{Dir: dir}
+}
+
+func (s *FileStore) path(symbol string) string {
+	return filepath.Join(s.Dir, url.QueryEscape(symbol)+".json")
+}
+
+// Save mescla a série recém-baixada, ainda sem os reparos de Clean, com o que já existe em disco
+// (ver mergeQuotes). Fechamentos nulos (NaN) são gravados como 0, já que JSON não representa NaN,
+// e continuam tratados como inválidos por Clean ao serem lidos.
+func (s *FileStore) Save(series Series) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, _ := s.load(series.Symbol)
+	stored.Symbol = series.Symbol
+	stored.Currency = series.Currency
+	stored.FetchedAt = time.Now()
+	stored.Quotes = mergeQuotes(stored.Quotes, storableQuotes(series.Quotes))
+	stored.Dividends = mergeDividends(stored.Dividends, series.Dividends)
+	stored.Splits = mergeSplits(stored.Splits, series.Splits)
+
+	if err := os.MkdirAll(s.Dir, 0755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(stored)
+	if err != nil {
+		return err
+	}
+
+	// Grava em arquivo temporário e renomeia para não deixar JSON pela metade
+	tmp, err := os.CreateTemp(s.Dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	tmp.Close()
+	return os.Rename(tmp.Name(), s.path(series.Symbol))
+}
+
+// Load devolve a última série conhecida do símbolo no período, marcada como desatualizada, com AsOf
+// na data da última cotação guardada. As cotações vêm sem reparos: quem lê aplica Clean.
+func (s *FileStore) Load(symbol string, startDate, endDate time.Time) (Series, error) {
+	s.mu.Lock()
+	stored, err := s.load(symbol)
+	s.mu.Unlock()
+	if err != nil {
+		return Series{}, err
+	}
+
+	series := Series{
+		Symbol:   stored.Symbol,
+		Currency: stored.Currency,
+		Stale:    true,
+	}
+	if n := len(stored.Quotes); n > 0 {
+		series.AsOf = stored.Quotes[n-1].Date
+	}
+	series.Quotes = quotesBetween(stored.Quotes, startDate, endDate)
+	from, to := DateKey(startDate), DateKey(endDate)
+	for _, d := range stored.Dividends {
+		if day := DateKey(d.Date); day >= from && day <= to {
+			series.Dividends = append(series.Dividends, d)
+		}
+	}
+	for _, sp := range stored.Splits {
+		if day := DateKey(sp.Date); day >= from && day <= to {
+			series.Splits = append(series.Splits, sp)
+		}
+	}
+	return series, nil
+}
+
+func (s *FileStore) load(symbol string) (storedSeries, error) {
+	var stored storedSeries
+	data, err := os.ReadFile(s.path(symbol))
+	if err != nil {
+		return stored, err
+	}
+	err = json.Unmarshal(data, &stored)
+	return stored, err
+}
+
+// storableQuotes troca fechamentos nulos (NaN) por 0 para a gravação em JSON
+func storableQuotes(quotes []Quote) []Quote {
+	out := make([]Quote, len(quotes))
+	for i, q := range quotes {
+		if math.IsNaN(q.Close) {
+			q.Close = 0
+		}
+		if math.IsNaN(q.AdjClose) {
+			q.AdjClose = 0
+		}
+		out[i] = q
+	}
+	return out
+}
+
+// mergeQuotes substitui pela série nova todo o intervalo de datas que ela cobre, sem intercalar pontos
+// antigos. Os trechos antigos fora desse intervalo são reescalados para a base de ajuste da série nova:
+// desdobramentos e proventos posteriores ao download antigo mudam todo o histórico ajustado. Sem uma
+// data em comum não há como alinhar as duas bases e a série antiga é descartada.
+func mergeQuotes(old, fresh []Quote) []Quote {
+	if len(fresh) == 0 {
+		return old
+	}
+	closeRatio, adjRatio, ok := adjustmentRatios(old, fresh)
+	if !ok {
+		return append([]Quote(nil), fresh...)
+	}
+
+	first, last := DateKey(fresh[0].Date), DateKey(fresh[len(fresh)-1].Date)
+	rescale := func(q Quote) Quote {
+		adj := q.AdjClose * adjRatio
+		q = q.Scale(closeRatio)
+		q.AdjClose = adj
+		return q
+	}
+	merged := make([]Quote, 0, len(old)+len(fresh))
+	for _, q := range old {
+		if DateKey(q.Date) < first {
+			merged = append(merged, rescale(q))
+		}
+	}
+	merged = append(merged, fresh...)
+	for _, q := range old {
+		if DateKey(q.Date) > last {
+			merged = append(merged, rescale(q))
+		}
+	}
+	return merged
+}
+
+// adjustmentRatios compara as duas séries na primeira data em comum com preços válidos e devolve
+// quanto o fechamento e o fechamento ajustado da série nova diferem dos da antiga
+func adjustmentRatios(old, fresh []Quote) (closeRatio, adjRatio float64, ok bool) {
+	valid := func(q Quote) bool { return q.Close > 0 && q.AdjClose > 0 }
+	byDate := make(map[string]Quote, len(old))
+	for _, q := range old {
+		if valid(q) {
+			byDate[DateKey(q.Date)] = q
+		}
+	}
+	for _, f := range fresh {
+		if o, found := byDate[DateKey(f.Date)]; found && valid(f) {
+			return f.Close / o.Close, f.AdjClose / o.AdjClose, true
+		}
+	}
+	return 0, 0, false
+}
+
+func mergeDividends(old, fresh []Dividend) []Dividend {
+	seen := make(map[string]bool)
+	for _, d := range fresh {
+		seen[DateKey(d.Date)] = true
+	}
+	merged := append([]Dividend(nil), fresh...)
+	for _, d := range old {
+		if !seen[DateKey(d.Date)] {
+			merged = append(merged, d)
+		}
+	}
+	sortDividends(merged)
+	return merged
+}
+
+func mergeSplits(old, fresh []Split) []Split {
+	seen := make(map[string]bool)
+	for _, sp := range fresh {
+		seen[DateKey(sp.Date)] = true
+	}
+	merged := append([]Split(nil), fresh...)
+	for _, sp := range old {
+		if !seen[DateKey(sp.Date)] {
+			merged = append(merged, sp)
+		}
+	}
+	sortSplits(merged)
+	return merged
+}
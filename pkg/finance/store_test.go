@@ -0,0 +1,133 @@
+package finance
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func day(d int) time.Time {
+	return time.Date(2024, time.January, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestMergeQuotes(t *testing.T) {
+	// Série antiga de 1 a 5/jan; desdobramento 2:1 depois do download: a nova (3 a 7/jan) vem pela metade
+	var old []Quote
+	for d := 1; d <= 5; d++ {
+		old = append(old, Quote{Date: day(d), Close: 100, AdjClose: 90})
+	}
+	var fresh []Quote
+	for d := 3; d <= 7; d++ {
+		if d == 4 {
+			continue // Ausente na nova: não pode voltar da antiga
+		}
+		fresh = append(fresh, Quote{Date: day(d), Close: 50, AdjClose: 50})
+	}
+
+	merged := mergeQuotes(old, fresh)
+	var got []string
+	for _, q := range merged {
+		got = append(got, fmt.Sprintf("%d:%.0f/%.0f", q.Date.Day(), q.Close, q.AdjClose))
+	}
+	want := "[1:50/50 2:50/50 3:50/50 5:50/50 6:50/50 7:50/50]"
+	if fmt.Sprint(got) != want {
+		t.Errorf("mergeQuotes = %v, esperado %s", got, want)
+	}
+
+	// Sem data em comum não há como alinhar as bases: só a série nova fica
+	disjoint := mergeQuotes(old, []Quote{{Date: day(10), Close: 50, AdjClose: 50}})
+	if len(disjoint) != 1 || disjoint[0].Date.Day() != 10 {
+		t.Errorf("séries sem data em comum = %v, esperado só a nova", disjoint)
+	}
+}
+
+func TestFileStoreAsOfAndNulls(t *testing.T) {
+	store := NewFileStore(t.TempDir())
+	quotes := []Quote{
+		{Date: day(2), Close: 10, AdjClose: 10},
+		{Date: day(3), Close: math.NaN(), AdjClose: math.NaN()},
+		{Date: day(4), Close: 11, AdjClose: 11},
+	}
+	if err := store.Save(Series{Symbol: "X", Currency: "USD", Quotes: quotes}); err != nil {
+		t.Fatal(err)
+	}
+	series, err := store.Load("X", day(1), day(31))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !series.Stale || !series.AsOf.Equal(day(4)) {
+		t.Errorf("Stale = %v, AsOf = %v; esperado última cotação %v", series.Stale, series.AsOf, day(4))
+	}
+	if len(series.Quotes) != 3 || series.Quotes[1].Close != 0 {
+		t.Errorf("cotações = %v, esperado o nulo guardado como 0", series.Quotes)
+	}
+}
+
+// chartJSON monta uma resposta da Chart API com fechamentos diários a partir de 2/jan/2024 (nil = null)
+func chartJSON(closes ...*float64) string {
+	var ts, cl string
+	for i, c := range closes {
+		sep := ","
+		if i == 0 {
+			sep = ""
+		}
+		ts += fmt.Sprintf("%s%d", sep, day(2+i).Add(15*time.Hour).Unix())
+		if c == nil {
+			cl += sep + "null"
+		} else {
+			cl += fmt.Sprintf("%s%g", sep, *c)
+		}
+	}
+	return fmt.Sprintf(`{"chart":{"result":[{"meta":{"currency":"USD","gmtoffset":0},"timestamp":[%s],
+		"indicators":{"quote":[{"close":[%s]}],"adjclose":[{"adjclose":[%s]}]}}]}}`, ts, cl, cl)
+}
+
+func TestClientCachesRawSeries(t *testing.T) {
+	price := func(v float64) *float64 { return &v }
+	var failing int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(&failing) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(chartJSON(price(10), nil, price(11))))
+	}))
+	defer srv.Close()
+
+	cfg := testConfig()
+	cfg.MaxRetries = 0
+	client := NewClient()
+	client.BaseURL = srv.URL + "/"
+	client.Transport = NewTransport(cfg)
+	client.Cache = NewFileStore(t.TempDir())
+	ctx := context.Background()
+
+	// Ao vivo, com as regras padrão, o fechamento nulo é descartado
+	series, err := client.GetSeries(ctx, "X", day(1), day(31))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(series.Quotes) != 2 || series.Quality.Nulls != 1 {
+		t.Fatalf("ao vivo: %d cotações, %d nulos; esperado 2 e 1", len(series.Quotes), series.Quality.Nulls)
+	}
+
+	// Do cache, outra regra de reparo ainda vê o ponto bruto e o preenche
+	atomic.StoreInt32(&failing, 1)
+	client.Quality.Invalid = RepairFill
+	series, err = client.GetSeries(ctx, "X", day(1), day(31))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !series.Stale || len(series.Quotes) != 3 || series.Quotes[1].Close != 10 || series.Quality.Repaired != 1 {
+		t.Errorf("do cache: Stale %v, cotações %v, reparados %d; esperado o nulo preenchido com 10",
+			series.Stale, series.Quotes, series.Quality.Repaired)
+	}
+	if !series.AsOf.Equal(day(4)) {
+		t.Errorf("AsOf = %v, esperado a última cotação %v", series.AsOf, day(4))
+	}
+}
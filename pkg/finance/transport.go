@@ -0,0 +1,215 @@
+package finance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// StatusError é devolvido quando o provedor responde com status diferente de 200
+type StatusError struct {
+	Code       int
+	RetryAfter time.Duration // Espera pedida pelo servidor (cabeçalho Retry-After)
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("status %d", e.Code)
+}
+
+// retryable indica se vale tentar de novo (limite de taxa ou erro do servidor)
+func (e *StatusError) retryable() bool {
+	return e.Code == http.StatusTooManyRequests || e.Code >= 500
+}
+
+// TransportConfig configura as chamadas HTTP aos provedores de cotações
+type TransportConfig struct {
+	Timeout     time.Duration // Tempo máximo de cada tentativa
+	MaxRetries  int           // Novas tentativas após a primeira em 429/5xx/erro de rede
+	BaseBackoff time.Duration // Espera inicial entre tentativas (dobra a cada tentativa, com jitter)
+	MaxBackoff  time.Duration // Teto da espera entre tentativas
+
+	RatePerSecond float64 // Requisições por segundo permitidas (token bucket global). 0 desativa
+	Burst         int     // Rajada máxima do token bucket
+
+	BreakerThreshold int           // Falhas consecutivas que abrem o circuito. 0 desativa
+	BreakerCooldown  time.Duration // Tempo com o circuito aberto antes de testar de novo
+
+	UserAgent string
+}
+
+// DefaultTransportConfig devolve valores conservadores para a API pública do Yahoo
+func DefaultTransportConfig() TransportConfig {
+	return TransportConfig{
+		Timeout:          10 * time.Second,
+		MaxRetries:       3,
+		BaseBackoff:      500 * time.Millisecond,
+		MaxBackoff:       8 * time.Second,
+		RatePerSecond:    4,
+		Burst:            8,
+		BreakerThreshold: 5,
+		BreakerCooldown:  30 * time.Second,
+		UserAgent:        "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36",
+	}
+}
+
+// Transport faz as chamadas HTTP com timeout, retentativas, limite de taxa e circuit breaker.
+// Deve ser compartilhado entre clientes para que limite e circuito valham para o processo todo.
+type Transport struct {
+	cfg     TransportConfig
+	http    *http.Client
+	limiter *tokenBucket
+	breaker *circuitBreaker
+
+	mu  sync.Mutex
+	rng *rand.Rand
+}
+
+// DefaultTransport é o transporte compartilhado usado por NewClient
+var DefaultTransport = NewTransport(DefaultTransportConfig())
+
+// NewTransport cria um transporte com a configuração informada
+func NewTransport(cfg TransportConfig) *Transport {
+	t := &Transport{
+		cfg:  cfg,
+		http: &http.Client{Timeout: cfg.Timeout},
+		rng:  rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+	if cfg.RatePerSecond > 0 {
+		t.limiter = newTokenBucket(cfg.RatePerSecond, cfg.Burst)
+	}
+	if cfg.BreakerThreshold > 0 {
+		t.breaker = newCircuitBreaker(cfg.BreakerThreshold, cfg.BreakerCooldown)
+	}
+	return t
+}
+
+// Get busca a URL e devolve o corpo da resposta, tentando de novo em falhas transitórias
+func (t *Transport) Get(ctx context.Context, url string) ([]byte, error) {
+	var probe bool
+	if t.breaker != nil {
+		var ok bool
+		if ok, probe = t.breaker.allow(); !ok {
+			return nil, ErrCircuitOpen
+		}
+	}
+
+	body, err := t.getWithRetries(ctx, url)
+
+	if t.breaker != nil {
+		var statusErr *StatusError
+		switch {
+		case err == nil, errors.As(err, &statusErr) && !statusErr.retryable():
+			// Provedor respondeu (mesmo que com 404): está no ar
+			t.breaker.record(probe, true)
+		case ctx.Err() != nil:
+			// Cancelado pela requisição de origem: não conta como falha do provedor
+			t.breaker.release(probe)
+		default:
+			t.breaker.record(probe, false)
+		}
+	}
+	return body, err
+}
+
+func (t *Transport) getWithRetries(ctx context.Context, url string) ([]byte, error) {
+	var lastErr error
+	for attempt := 0; attempt <= t.cfg.MaxRetries; attempt++ {
+		if attempt > 0 {
+			if err := sleep(ctx, t.backoff(attempt, lastErr)); err != nil {
+				return nil, err
+			}
+		}
+		if t.limiter != nil {
+			if err := t.limiter.wait(ctx); err != nil {
+				return nil, err
+			}
+		}
+
+		body, err := t.do(ctx, url)
+		if err == nil {
+			return body, nil
+		}
+		lastErr = err
+
+		// Cancelamento da requisição de origem ou erro definitivo (ex: 404): não insiste
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		var statusErr *StatusError
+		if errors.As(err, &statusErr) && !statusErr.retryable() {
+			return nil, err
+		}
+	}
+	return nil, lastErr
+}
+
+// do executa uma única tentativa
+func (t *Transport) do(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if t.cfg.UserAgent != "" {
+		req.Header.Set("User-Agent", t.cfg.UserAgent)
+	}
+
+	resp, err := t.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &StatusError{Code: resp.StatusCode, RetryAfter: retryAfter(resp)}
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// retryAfter lê o cabeçalho Retry-After em segundos, se presente
+func retryAfter(resp *http.Response) time.Duration {
+	secs, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if err != nil || secs <= 0 {
+		return 0
+	}
+	return time.Duration(secs) * time.Second
+}
+
+// backoff calcula a espera antes da tentativa: exponencial com jitter completo,
+// respeitando o Retry-After do servidor quando for maior.
+func (t *Transport) backoff(attempt int, lastErr error) time.Duration {
+	ceiling := t.cfg.BaseBackoff << uint(attempt-1)
+	if ceiling <= 0 || ceiling > t.cfg.MaxBackoff {
+		ceiling = t.cfg.MaxBackoff
+	}
+
+	t.mu.Lock()
+	wait := time.Duration(t.rng.Int63n(int64(ceiling) + 1))
+	t.mu.Unlock()
+
+	var statusErr *StatusError
+	if errors.As(lastErr, &statusErr) && statusErr.RetryAfter > wait {
+		wait = statusErr.RetryAfter
+		if wait > t.cfg.MaxBackoff {
+			wait = t.cfg.MaxBackoff
+		}
+	}
+	return wait
+}
+
+// sleep espera d ou até o contexto ser cancelado
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,199 @@
+package finance
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testConfig devolve uma configuração rápida, sem limite de taxa e sem circuit breaker
+func testConfig() TransportConfig {
+	return TransportConfig{
+		Timeout:     2 * time.Second,
+		MaxRetries:  3,
+		BaseBackoff: time.Millisecond,
+		MaxBackoff:  5 * time.Millisecond,
+	}
+}
+
+// failingServer responde com os status informados em sequência e depois com 200 "ok"
+func failingServer(t *testing.T, statuses ...int) (*httptest.Server, *int32) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(atomic.AddInt32(&calls, 1))
+		if n <= len(statuses) {
+			w.WriteHeader(statuses[n-1])
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+func TestTransportRetries(t *testing.T) {
+	tests := []struct {
+		name      string
+		statuses  []int
+		wantCalls int32
+		wantCode  int // 0 = sucesso
+	}{
+		{"sucesso direto", nil, 1, 0},
+		{"429 e depois sucesso", []int{429}, 2, 0},
+		{"5xx e depois sucesso", []int{500, 502, 503}, 4, 0},
+		{"5xx além das tentativas", []int{500, 500, 500, 500, 500}, 4, 500},
+		{"404 não é repetido", []int{404}, 1, 404},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, calls := failingServer(t, tt.statuses...)
+			body, err := NewTransport(testConfig()).Get(context.Background(), srv.URL)
+
+			if got := atomic.LoadInt32(calls); got != tt.wantCalls {
+				t.Errorf("chamadas = %d, esperado %d", got, tt.wantCalls)
+			}
+			if tt.wantCode == 0 {
+				if err != nil || string(body) != "ok" {
+					t.Fatalf("Get = %q, %v; esperado ok", body, err)
+				}
+				return
+			}
+			var statusErr *StatusError
+			if !errors.As(err, &statusErr) || statusErr.Code != tt.wantCode {
+				t.Fatalf("erro = %v, esperado status %d", err, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestTransportRetryAfter(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	cfg := testConfig()
+	cfg.MaxBackoff = 2 * time.Second
+	start := time.Now()
+	if _, err := NewTransport(cfg).Get(context.Background(), srv.URL); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("nova tentativa após %v, antes do Retry-After de 1s", elapsed)
+	}
+}
+
+func TestTransportBreakerOpensAndHalfOpens(t *testing.T) {
+	var calls int32
+	var healthy int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		if atomic.LoadInt32(&healthy) == 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	cfg := testConfig()
+	cfg.MaxRetries = 0
+	cfg.BreakerThreshold = 2
+	cfg.BreakerCooldown = 50 * time.Millisecond
+	tr := NewTransport(cfg)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if _, err := tr.Get(ctx, srv.URL); err == nil {
+			t.Fatal("esperado erro do servidor")
+		}
+	}
+	if _, err := tr.Get(ctx, srv.URL); err != ErrCircuitOpen {
+		t.Fatalf("erro = %v, esperado circuito aberto", err)
+	}
+	if got := atomic.LoadInt32(&calls); got != 2 {
+		t.Errorf("circuito aberto chamou o servidor: %d chamadas", got)
+	}
+
+	// Meio-aberto: a chamada de teste falha e o circuito reabre
+	time.Sleep(60 * time.Millisecond)
+	if _, err := tr.Get(ctx, srv.URL); err == nil || err == ErrCircuitOpen {
+		t.Fatalf("chamada de teste = %v, esperado erro do servidor", err)
+	}
+	if _, err := tr.Get(ctx, srv.URL); err != ErrCircuitOpen {
+		t.Fatalf("após teste com falha, erro = %v, esperado circuito aberto", err)
+	}
+
+	// Meio-aberto de novo: a chamada de teste passa e o circuito fecha
+	atomic.StoreInt32(&healthy, 1)
+	time.Sleep(60 * time.Millisecond)
+	for i := 0; i < 3; i++ {
+		if _, err := tr.Get(ctx, srv.URL); err != nil {
+			t.Fatalf("chamada %d após fechar: %v", i, err)
+		}
+	}
+}
+
+func TestBreakerOnlyProbeClearsProbing(t *testing.T) {
+	cb := newCircuitBreaker(1, time.Millisecond)
+
+	// Chamada normal em andamento quando o circuito abre
+	ok, inFlight := cb.allow()
+	if !ok || inFlight {
+		t.Fatalf("allow = %v, %v; esperado chamada normal", ok, inFlight)
+	}
+	cb.record(false, false)
+	time.Sleep(2 * time.Millisecond)
+
+	ok, probe := cb.allow()
+	if !ok || !probe {
+		t.Fatalf("allow = %v, %v; esperado chamada de teste", ok, probe)
+	}
+	// A chamada normal antiga termina (cancelada) durante o teste: não libera outro teste
+	cb.release(inFlight)
+	if ok, _ := cb.allow(); ok {
+		t.Fatal("segunda chamada de teste liberada enquanto a primeira está em andamento")
+	}
+	cb.record(probe, true)
+	if ok, probe := cb.allow(); !ok || probe {
+		t.Fatalf("allow = %v, %v; esperado circuito fechado", ok, probe)
+	}
+}
+
+func TestTransportContextCancel(t *testing.T) {
+	block := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-block:
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+	defer close(block)
+
+	cfg := testConfig()
+	cfg.BreakerThreshold = 1
+	cfg.BreakerCooldown = time.Minute
+	tr := NewTransport(cfg)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+	if _, err := tr.Get(ctx, srv.URL); !errors.Is(err, context.Canceled) {
+		t.Fatalf("erro = %v, esperado cancelamento", err)
+	}
+
+	// O cancelamento não conta como falha do provedor
+	if ok, _ := tr.breaker.allow(); !ok {
+		t.Error("cancelamento abriu o circuito")
+	}
+}